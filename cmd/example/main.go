@@ -1,19 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/Devalanx/must_go/pkg/must_go"
 )
 
 type User struct {
 	ID    int    `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Name  string `json:"name" validate:"required,max=100"`
+	Email string `json:"email" validate:"required,email"`
 }
 
 // Mock database
@@ -24,39 +24,18 @@ var users = map[int]User{
 
 func getUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse user ID from query parameter
-	userIDStr := r.URL.Query().Get("id")
-	if userIDStr == "" {
-		must_go.MustBadRequest(fmt.Errorf("user id is required"))
-	}
-
-	// Parse user ID to integer
-	userID := must_go.MustParse(strconv.Atoi(userIDStr))
+	userID := must_go.MustQuery[int](r, "id")
 
 	// Get user from database
 	user, exists := users[userID]
-	if !exists {
-		must_go.MustNotFound(fmt.Errorf("user with id %d not found", userID))
-	}
+	user = must_go.MustOK(user, exists, http.StatusNotFound, "Resource not found")
 
 	// Return user as JSON
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
 
-func createUserHandler(w http.ResponseWriter, r *http.Request) {
-	// Parse JSON request body
-	var user User
-	err := json.NewDecoder(r.Body).Decode(&user)
-	must_go.MustHTTP(err, http.StatusBadRequest, "Invalid JSON format")
-
-	// Validate user data
-	if user.Name == "" {
-		must_go.MustValidation(fmt.Errorf("name is required"))
-	}
-	if user.Email == "" {
-		must_go.MustValidation(fmt.Errorf("email is required"))
-	}
-
+func createUser(ctx context.Context, user User) (User, error) {
 	// Check if user already exists
 	for _, existingUser := range users {
 		if existingUser.Email == user.Email {
@@ -68,20 +47,12 @@ func createUserHandler(w http.ResponseWriter, r *http.Request) {
 	user.ID = len(users) + 1
 	users[user.ID] = user
 
-	// Return created user
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(user)
+	return user, nil
 }
 
 func updateUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse user ID from query parameter
-	userIDStr := r.URL.Query().Get("id")
-	if userIDStr == "" {
-		must_go.MustBadRequest(fmt.Errorf("user id is required"))
-	}
-
-	userID := must_go.MustParse(strconv.Atoi(userIDStr))
+	userID := must_go.MustQuery[int](r, "id")
 
 	// Check if user exists
 	_, exists := users[userID]
@@ -91,16 +62,10 @@ func updateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Parse JSON request body
 	var user User
-	err := json.NewDecoder(r.Body).Decode(&user)
-	must_go.MustHTTP(err, http.StatusBadRequest, "Invalid JSON format")
+	must_go.MustDecodeJSON(r, &user)
 
 	// Validate user data
-	if user.Name == "" {
-		must_go.MustValidation(fmt.Errorf("name is required"))
-	}
-	if user.Email == "" {
-		must_go.MustValidation(fmt.Errorf("email is required"))
-	}
+	must_go.Must(must_go.ValidateStruct(&user))
 
 	// Update user
 	user.ID = userID
@@ -113,12 +78,7 @@ func updateUserHandler(w http.ResponseWriter, r *http.Request) {
 
 func deleteUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse user ID from query parameter
-	userIDStr := r.URL.Query().Get("id")
-	if userIDStr == "" {
-		must_go.MustBadRequest(fmt.Errorf("user id is required"))
-	}
-
-	userID := must_go.MustParse(strconv.Atoi(userIDStr))
+	userID := must_go.MustQuery[int](r, "id")
 
 	// Check if user exists
 	_, exists := users[userID]
@@ -138,12 +98,13 @@ func main() {
 
 	// Register handlers
 	mux.HandleFunc("GET /users", getUserHandler)
-	mux.HandleFunc("POST /users", createUserHandler)
+	mux.Handle("POST /users", must_go.HandleJSON(createUser, must_go.WithSuccessStatus(http.StatusCreated)))
 	mux.HandleFunc("PUT /users", updateUserHandler)
 	mux.HandleFunc("DELETE /users", deleteUserHandler)
 
-	// Wrap with recovery middleware
-	handler := must_go.RecoveryMiddleware(mux)
+	// Wrap with recovery middleware, tagging every request with an id.
+	// NewRecovery only shows HTTPError messages to clients.
+	handler := must_go.RequestIDMiddleware(must_go.NewRecovery().Handler(mux))
 
 	log.Println("Server starting on :8080")
 	log.Println("Try these endpoints:")
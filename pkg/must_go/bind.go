@@ -0,0 +1,119 @@
+package must_go
+
+import (
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// DefaultMaxMemory is the memory limit used by Bind to parse multipart forms
+const DefaultMaxMemory = 32 << 20
+
+// bindSources are the struct tags Bind reads values from, in the order they
+// are consulted for a field's name
+var bindSources = []string{"query", "path", "header", "form"}
+
+// Bind fills the struct dst points to from the request and validates it.
+//
+// A JSON body is decoded into dst with DecodeJSON first. Fields tagged
+// query, path, header or form are then set from the named query parameter,
+// http.ServeMux path wildcard, header or form value, using the conversions
+// of MustQuery. Finally the validate tags are checked as by ValidateStruct.
+//
+// Values that cannot be converted are reported together in a 400 HTTPError,
+// validation failures in a 422 HTTPError. Both list every offending field
+// in Errors.
+func Bind(r *http.Request, dst any, opts ...DecodeOption) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		panic("must_go: Bind requires a non-nil pointer to a struct")
+	}
+
+	if isJSONRequest(r) {
+		if err := decodeJSONBody(r, dst, opts); err != nil {
+			return err
+		}
+	}
+
+	if errs := bindFields(r, rv.Elem()); len(errs) > 0 {
+		return HTTPError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Invalid request parameters",
+			Errors:     errs,
+		}
+	}
+	return ValidateStruct(dst)
+}
+
+// MustBind is like Bind but panics with the HTTPError
+func MustBind(r *http.Request, dst any, opts ...DecodeOption) {
+	Must(Bind(r, dst, opts...))
+}
+
+// isJSONRequest reports whether the request body is declared as JSON
+func isJSONRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// bindFields sets the tagged fields of a struct, descending into embedded
+// structs, and returns a FieldError for every value that does not convert
+func bindFields(r *http.Request, rv reflect.Value) []FieldError {
+	var errs []FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			errs = append(errs, bindFields(r, rv.Field(i))...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		for _, source := range bindSources {
+			name := sf.Tag.Get(source)
+			if name == "" || name == "-" {
+				continue
+			}
+			values := sourceValues(r, source, name)
+			if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+				continue
+			}
+			if err := parseParam(values, rv.Field(i)); err != nil {
+				errs = append(errs, FieldError{
+					Field:   name,
+					Code:    "invalid",
+					Message: "expected " + describeType(sf.Type),
+				})
+			}
+			break
+		}
+	}
+	return errs
+}
+
+// sourceValues returns the raw values of a parameter from one source
+func sourceValues(r *http.Request, source, name string) []string {
+	switch source {
+	case "query":
+		return r.URL.Query()[name]
+	case "path":
+		return pathValues(r, name)
+	case "header":
+		return r.Header.Values(name)
+	case "form":
+		if r.PostForm == nil {
+			if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+				r.ParseMultipartForm(DefaultMaxMemory)
+			} else {
+				r.ParseForm()
+			}
+		}
+		return r.PostForm[name]
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package must_go
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pagination struct {
+	Page  int `query:"page" validate:"min=1"`
+	Limit int `query:"limit" validate:"max=100"`
+}
+
+type listOrdersRequest struct {
+	pagination
+	UserID  int           `path:"id" validate:"required"`
+	Status  []string      `query:"status" validate:"max=3"`
+	Since   time.Time     `query:"since"`
+	Timeout time.Duration `header:"X-Timeout"`
+	Note    string        `json:"note" validate:"max=10"`
+}
+
+func TestBind(t *testing.T) {
+	var got listOrdersRequest
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}/orders", func(w http.ResponseWriter, r *http.Request) {
+		MustBind(r, &got)
+	})
+
+	req := httptest.NewRequest("POST", "/users/7/orders?page=2&limit=50&status=open,paid&since=2024-01-02", strings.NewReader(`{"note":"rush"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Timeout", "5s")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got.UserID != 7 || got.Page != 2 || got.Limit != 50 || got.Timeout != 5*time.Second || got.Note != "rush" {
+		t.Errorf("Unexpected binding: %+v", got)
+	}
+	if len(got.Status) != 2 || got.Status[1] != "paid" {
+		t.Errorf("Expected statuses [open paid], got: %v", got.Status)
+	}
+	if got.Since.Day() != 2 {
+		t.Errorf("Expected since 2024-01-02, got: %v", got.Since)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	var got struct {
+		Name string `form:"name" validate:"required"`
+		Age  int    `form:"age"`
+	}
+	form := url.Values{"name": {"John"}, "age": {"30"}}
+	req := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if err := Bind(req, &got); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if got.Name != "John" || got.Age != 30 {
+		t.Errorf("Unexpected binding: %+v", got)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		wantStatus int
+		wantFields []string
+	}{
+		{"invalid values", "/users/x/orders?page=two&limit=1", http.StatusBadRequest, []string{"page", "id"}},
+		{"violations", "/users/7/orders?page=-1&limit=500&status=a,b,c,d", http.StatusUnprocessableEntity, []string{"page", "limit", "status"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst listOrdersRequest
+			req := httptest.NewRequest("GET", tt.target, nil)
+			req.SetPathValue("id", strings.Split(tt.target, "/")[2])
+
+			httpErr, ok := Bind(req, &dst).(HTTPError)
+			if !ok {
+				t.Fatal("Expected HTTPError")
+			}
+			if httpErr.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got: %d", tt.wantStatus, httpErr.StatusCode)
+			}
+			if len(httpErr.Errors) != len(tt.wantFields) {
+				t.Fatalf("Expected %d field errors, got: %+v", len(tt.wantFields), httpErr.Errors)
+			}
+			for i, field := range tt.wantFields {
+				if httpErr.Errors[i].Field != field {
+					t.Errorf("Expected field %s at %d, got: %s", field, i, httpErr.Errors[i].Field)
+				}
+			}
+		})
+	}
+}
+
+func TestRecoveryRendersFieldErrors(t *testing.T) {
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var dst struct {
+			Name string `json:"name" validate:"required"`
+		}
+		MustBind(r, &dst)
+	}))
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got: %d", w.Code)
+	}
+	var body struct {
+		Error struct {
+			Message string       `json:"message"`
+			Errors  []FieldError `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Failed to decode body: %v", err)
+	}
+	if len(body.Error.Errors) != 1 || body.Error.Errors[0] != (FieldError{Field: "name", Code: "required", Message: "is required"}) {
+		t.Errorf("Unexpected field errors: %+v", body.Error.Errors)
+	}
+
+	problem := NewProblem(HTTPError{StatusCode: 422, Errors: body.Error.Errors}, req)
+	data, _ := json.Marshal(problem)
+	if !strings.Contains(string(data), `"errors":[{"field":"name","code":"required","message":"is required"}]`) {
+		t.Errorf("Expected errors member in problem document, got: %s", data)
+	}
+}
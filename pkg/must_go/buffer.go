@@ -0,0 +1,339 @@
+package must_go
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPanicBufferSize is the capacity of a PanicBuffer created with a
+// size of zero or less
+const DefaultPanicBufferSize = 100
+
+// PanicBuffer keeps the most recent panic reports in memory and serves them
+// for inspection. Attach it to a Recovery with WithPanicBuffer and mount it
+// on a debug route:
+//
+//	panics := must_go.NewPanicBuffer(200, must_go.WithClearAuthorizer(isAdmin))
+//	handler := must_go.NewRecovery(must_go.WithPanicBuffer(panics)).Handler(mux)
+//	http.Handle("/debug/panics", panics)
+//
+// GET lists the reports, newest first, as JSON or, for browsers, as HTML.
+// The query parameters status, path (a prefix), since and until (RFC 3339)
+// filter them, group=fingerprint groups them and format=json or format=html
+// overrides the Accept header. DELETE, or POST with action=clear, empties
+// the buffer if the clear authorizer allows it. Cross-site clear requests,
+// as told by the Sec-Fetch-Site or Origin headers, are rejected.
+//
+// Reports contain error messages and stack traces; protect the route like
+// any other debug endpoint.
+type PanicBuffer struct {
+	mu        sync.Mutex
+	reports   []PanicReport
+	next      int
+	full      bool
+	authorize func(*http.Request) bool
+}
+
+// PanicBufferOption configures a PanicBuffer
+type PanicBufferOption func(*PanicBuffer)
+
+// WithClearAuthorizer sets the function deciding whether a request may clear
+// the buffer. Without one every clear request is rejected with 403.
+// Cross-site requests are rejected before authorize is called, so an
+// authorizer relying on cookies cannot be used by other sites to clear the
+// buffer through an admin's browser; non-browser clients sending neither
+// Sec-Fetch-Site nor Origin are unaffected.
+func WithClearAuthorizer(authorize func(*http.Request) bool) PanicBufferOption {
+	return func(b *PanicBuffer) {
+		b.authorize = authorize
+	}
+}
+
+// NewPanicBuffer creates a buffer keeping the last size reports
+func NewPanicBuffer(size int, opts ...PanicBufferOption) *PanicBuffer {
+	if size <= 0 {
+		size = DefaultPanicBufferSize
+	}
+	b := &PanicBuffer{reports: make([]PanicReport, size)}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// WithPanicBuffer records every recovered panic and returned error in b
+func WithPanicBuffer(b *PanicBuffer) Option {
+	return WithObserver(func(r *http.Request, report PanicReport) {
+		b.Add(report)
+	})
+}
+
+// Add records a report, evicting the oldest one when the buffer is full
+func (b *PanicBuffer) Add(report PanicReport) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.reports[b.next] = report
+	b.next = (b.next + 1) % len(b.reports)
+	if b.next == 0 {
+		b.full = true
+	}
+}
+
+// Reports returns the buffered reports, newest first
+func (b *PanicBuffer) Reports() []PanicReport {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	n := b.next
+	if b.full {
+		n = len(b.reports)
+	}
+	reports := make([]PanicReport, 0, n)
+	for i := 1; i <= n; i++ {
+		reports = append(reports, b.reports[(b.next-i+len(b.reports))%len(b.reports)])
+	}
+	return reports
+}
+
+// Clear removes all reports
+func (b *PanicBuffer) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	clear(b.reports)
+	b.next = 0
+	b.full = false
+}
+
+// PanicGroup summarizes the buffered reports sharing a fingerprint
+type PanicGroup struct {
+	Fingerprint string    `json:"fingerprint"`
+	Count       int       `json:"count"`
+	Status      int       `json:"status"`
+	Message     string    `json:"message"`
+	Route       string    `json:"route,omitempty"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// panicFilter selects reports by the query parameters of the handler
+type panicFilter struct {
+	status []int
+	path   string
+	since  time.Time
+	until  time.Time
+}
+
+// match reports whether a report passes the filter
+func (f panicFilter) match(report PanicReport) bool {
+	if len(f.status) > 0 {
+		found := false
+		for _, s := range f.status {
+			found = found || s == report.Status
+		}
+		if !found {
+			return false
+		}
+	}
+	if f.path != "" && !strings.HasPrefix(report.Path, f.path) {
+		return false
+	}
+	if !f.since.IsZero() && report.Time.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && report.Time.After(f.until) {
+		return false
+	}
+	return true
+}
+
+// groupReports groups reports by fingerprint, largest groups first
+func groupReports(reports []PanicReport) []PanicGroup {
+	index := make(map[string]int)
+	var groups []PanicGroup
+	for _, report := range reports {
+		i, ok := index[report.Fingerprint]
+		if !ok {
+			i = len(groups)
+			index[report.Fingerprint] = i
+			groups = append(groups, PanicGroup{
+				Fingerprint: report.Fingerprint,
+				Status:      report.Status,
+				Message:     report.Message,
+				Route:       report.Route,
+				FirstSeen:   report.Time,
+				LastSeen:    report.Time,
+			})
+		}
+		g := &groups[i]
+		g.Count++
+		if report.Time.Before(g.FirstSeen) {
+			g.FirstSeen = report.Time
+		}
+		if report.Time.After(g.LastSeen) {
+			g.LastSeen = report.Time
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].LastSeen.After(groups[j].LastSeen)
+	})
+	return groups
+}
+
+// ServeHTTP implements http.Handler, see PanicBuffer
+func (b *PanicBuffer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	HandlerFunc(b.serve).ServeHTTP(w, r)
+}
+
+// serve lists or clears the buffer
+func (b *PanicBuffer) serve(w http.ResponseWriter, r *http.Request) (err error) {
+	defer Handle(&err)
+
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return b.list(w, r)
+	case r.Method == http.MethodDelete || (r.Method == http.MethodPost && r.FormValue("action") == "clear"):
+		if crossSite(r) || b.authorize == nil || !b.authorize(r) {
+			return HTTPError{StatusCode: http.StatusForbidden, Message: "Forbidden"}
+		}
+		b.Clear()
+		if r.Method == http.MethodPost {
+			// Back to the listing for the HTML form
+			http.Redirect(w, r, r.URL.Path, http.StatusSeeOther)
+			return nil
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Allow", "GET, HEAD, POST, DELETE")
+	return HTTPError{StatusCode: http.StatusMethodNotAllowed, Message: "Method not allowed"}
+}
+
+// crossSite reports whether a browser sent r from another origin. The
+// Sec-Fetch-Site header is trusted when present, otherwise the host of the
+// Origin header is compared with the request's host.
+func crossSite(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site != "same-origin" && site != "none"
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	u, err := url.Parse(origin)
+	return err != nil || u.Host != r.Host
+}
+
+// list writes the filtered reports or groups
+func (b *PanicBuffer) list(w http.ResponseWriter, r *http.Request) error {
+	filter := panicFilter{
+		status: MustQueryOr[[]int](r, "status", nil),
+		path:   MustQueryOr(r, "path", ""),
+		since:  MustQueryOr(r, "since", time.Time{}),
+		until:  MustQueryOr(r, "until", time.Time{}),
+	}
+	grouped := MustQueryOr(r, "group", "") == "fingerprint"
+
+	var reports []PanicReport
+	for _, report := range b.Reports() {
+		if filter.match(report) {
+			reports = append(reports, report)
+		}
+	}
+	page := panicPage{Path: r.URL.Path, Query: r.URL.Query(), Count: len(reports)}
+	if grouped {
+		page.Groups = groupReports(reports)
+	} else {
+		page.Reports = reports
+	}
+
+	if wantsHTML(r) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		return panicPageTemplate.Execute(w, page)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(page)
+}
+
+// wantsHTML reports whether the listing is rendered as HTML: explicitly with
+// format=html, otherwise when the client prefers text/html over JSON
+func wantsHTML(r *http.Request) bool {
+	switch r.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+	ranges := parseAccept(r.Header.Get("Accept"))
+	htmlQ, htmlSpec := matchAccept(ranges, "text/html")
+	jsonQ, jsonSpec := matchAccept(ranges, "application/json")
+	return htmlSpec > 0 && (htmlQ > jsonQ || (htmlQ == jsonQ && htmlSpec > jsonSpec))
+}
+
+// panicPage is the listing served by PanicBuffer
+type panicPage struct {
+	Path    string              `json:"-"`
+	Query   map[string][]string `json:"-"`
+	Count   int                 `json:"count"`
+	Reports []PanicReport       `json:"reports,omitempty"`
+	Groups  []PanicGroup        `json:"groups,omitempty"`
+}
+
+// panicPageTemplate renders a panicPage as HTML
+var panicPageTemplate = template.Must(template.New("panics").Funcs(template.FuncMap{
+	"param": func(q map[string][]string, key string) string {
+		if v := q[key]; len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>Recovered panics</title></head>
+<body>
+<h1>Recovered panics ({{.Count}})</h1>
+<form method="get" action="{{.Path}}">
+<input name="status" placeholder="status" value="{{param .Query "status"}}">
+<input name="path" placeholder="path prefix" value="{{param .Query "path"}}">
+<input name="since" placeholder="since (RFC 3339)" value="{{param .Query "since"}}">
+<input name="until" placeholder="until (RFC 3339)" value="{{param .Query "until"}}">
+<label><input type="checkbox" name="group" value="fingerprint"{{if param .Query "group"}} checked{{end}}> group by fingerprint</label>
+<input type="hidden" name="format" value="html">
+<button>Filter</button>
+</form>
+<form method="post" action="{{.Path}}">
+<input type="hidden" name="action" value="clear">
+<button>Clear</button>
+</form>
+{{- if .Groups}}
+<table>
+<tr><th>Count</th><th>Status</th><th>Message</th><th>Route</th><th>First seen</th><th>Last seen</th><th>Fingerprint</th></tr>
+{{- range .Groups}}
+<tr><td>{{.Count}}</td><td>{{.Status}}</td><td>{{.Message}}</td><td>{{.Route}}</td><td>{{.FirstSeen.Format "2006-01-02 15:04:05"}}</td><td>{{.LastSeen.Format "2006-01-02 15:04:05"}}</td><td>{{.Fingerprint}}</td></tr>
+{{- end}}
+</table>
+{{- else}}
+<table>
+<tr><th>Time</th><th>Status</th><th>Request</th><th>Message</th><th>Request ID</th></tr>
+{{- range .Reports}}
+<tr><td>{{.Time.Format "2006-01-02 15:04:05"}}</td><td>{{.Status}}</td><td>{{.Method}} {{.Path}}</td><td>{{.Message}}
+{{- if .Stack}}
+<details><summary>stack</summary><pre>
+{{- range .Stack}}
+{{.}}
+{{- end}}
+</pre></details>
+{{- end}}</td><td>{{.RequestID}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,155 @@
+package must_go
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPanicBufferRing(t *testing.T) {
+	b := NewPanicBuffer(3)
+	for i := 1; i <= 5; i++ {
+		b.Add(PanicReport{Status: 500 + i})
+	}
+
+	reports := b.Reports()
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 reports, got: %d", len(reports))
+	}
+	for i, want := range []int{505, 504, 503} {
+		if reports[i].Status != want {
+			t.Errorf("Expected status %d at %d, got: %d", want, i, reports[i].Status)
+		}
+	}
+
+	b.Clear()
+	if len(b.Reports()) != 0 {
+		t.Errorf("Expected empty buffer after Clear, got: %d reports", len(b.Reports()))
+	}
+}
+
+func TestPanicBufferHandler(t *testing.T) {
+	buffer := NewPanicBuffer(10, WithClearAuthorizer(func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer admin"
+	}))
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		MustNotFound(errNoSuchUser)
+	})
+	mux.HandleFunc("GET /orders", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	handler := NewRecovery(WithPanicBuffer(buffer)).Handler(mux)
+	for _, target := range []string{"/users/1", "/users/2", "/orders"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	buffer.Add(PanicReport{Status: 500, Path: "/old", Time: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Fingerprint: "old"})
+
+	get := func(target string, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		req.Header.Set("Accept", accept)
+		w := httptest.NewRecorder()
+		buffer.ServeHTTP(w, req)
+		return w
+	}
+
+	tests := []struct {
+		name      string
+		target    string
+		wantCount int
+	}{
+		{"all", "/debug/panics", 4},
+		{"status", "/debug/panics?status=404", 2},
+		{"statuses", "/debug/panics?status=404,500", 4},
+		{"path prefix", "/debug/panics?path=/users", 2},
+		{"since", "/debug/panics?since=2024-01-01", 3},
+		{"until", "/debug/panics?until=2021-01-01T00:00:00Z", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := get(tt.target, "application/json")
+			var page struct {
+				Count   int           `json:"count"`
+				Reports []PanicReport `json:"reports"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+				t.Fatalf("Failed to decode %q: %v", w.Body.String(), err)
+			}
+			if page.Count != tt.wantCount || len(page.Reports) != tt.wantCount {
+				t.Errorf("Expected %d reports, got: %d", tt.wantCount, len(page.Reports))
+			}
+		})
+	}
+
+	w := get("/debug/panics?group=fingerprint", "application/json")
+	var grouped struct {
+		Groups []PanicGroup `json:"groups"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &grouped); err != nil {
+		t.Fatal(err)
+	}
+	if len(grouped.Groups) != 3 || grouped.Groups[0].Count != 2 || grouped.Groups[0].Route != "GET /users/{id}" {
+		t.Errorf("Expected the two 404s grouped first, got: %+v", grouped.Groups)
+	}
+
+	w = get("/debug/panics", "text/html,application/xhtml+xml,*/*;q=0.8")
+	if ct := w.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" || !strings.Contains(w.Body.String(), "Recovered panics (4)") {
+		t.Errorf("Expected HTML listing, got %s: %s", ct, w.Body.String())
+	}
+	if w = get("/debug/panics?status=abc", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid filter, got: %d", w.Code)
+	}
+}
+
+func TestPanicBufferClear(t *testing.T) {
+	buffer := NewPanicBuffer(10, WithClearAuthorizer(func(r *http.Request) bool {
+		return r.Header.Get("Authorization") == "Bearer admin"
+	}))
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		auth       string
+		header     http.Header
+		wantStatus int
+		wantEmpty  bool
+	}{
+		{"unauthorized", "DELETE", "/debug/panics", "", nil, http.StatusForbidden, false},
+		{"delete", "DELETE", "/debug/panics", "Bearer admin", nil, http.StatusNoContent, true},
+		{"form post", "POST", "/debug/panics?action=clear", "Bearer admin", nil, http.StatusSeeOther, true},
+		{"same-origin form post", "POST", "/debug/panics?action=clear", "Bearer admin", http.Header{"Sec-Fetch-Site": {"same-origin"}, "Origin": {"http://example.com"}}, http.StatusSeeOther, true},
+		{"cross-site form post", "POST", "/debug/panics?action=clear", "Bearer admin", http.Header{"Sec-Fetch-Site": {"cross-site"}}, http.StatusForbidden, false},
+		{"foreign origin", "POST", "/debug/panics?action=clear", "Bearer admin", http.Header{"Origin": {"https://evil.example"}}, http.StatusForbidden, false},
+		{"other method", "PUT", "/debug/panics", "Bearer admin", nil, http.StatusMethodNotAllowed, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buffer.Add(PanicReport{Status: 500})
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			req.Header.Set("Authorization", tt.auth)
+			for k, v := range tt.header {
+				req.Header[k] = v
+			}
+			w := httptest.NewRecorder()
+			buffer.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got: %d", tt.wantStatus, w.Code)
+			}
+			if empty := len(buffer.Reports()) == 0; empty != tt.wantEmpty {
+				t.Errorf("Expected empty=%v, got: %v", tt.wantEmpty, empty)
+			}
+		})
+	}
+
+	// Without an authorizer clearing is always forbidden
+	w := httptest.NewRecorder()
+	NewPanicBuffer(1).ServeHTTP(w, httptest.NewRequest("DELETE", "/", nil))
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected 403 without authorizer, got: %d", w.Code)
+	}
+}
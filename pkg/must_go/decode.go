@@ -0,0 +1,126 @@
+package must_go
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxBodyBytes is the request body limit used by DecodeJSON
+const DefaultMaxBodyBytes = 1 << 20
+
+// DecodeOption configures DecodeJSON and MustDecodeJSON
+type DecodeOption func(*decodeConfig)
+
+type decodeConfig struct {
+	maxBytes      int64
+	allowUnknown  bool
+	allowTrailing bool
+}
+
+// MaxBodyBytes sets the request body limit. Larger bodies are rejected with
+// 413 Request Entity Too Large.
+func MaxBodyBytes(n int64) DecodeOption {
+	return func(c *decodeConfig) {
+		c.maxBytes = n
+	}
+}
+
+// AllowUnknownFields accepts object keys that do not match any field
+func AllowUnknownFields() DecodeOption {
+	return func(c *decodeConfig) {
+		c.allowUnknown = true
+	}
+}
+
+// AllowTrailingData accepts data after the first JSON value
+func AllowTrailingData() DecodeOption {
+	return func(c *decodeConfig) {
+		c.allowTrailing = true
+	}
+}
+
+// DecodeJSON strictly decodes the request body into v. The body is limited
+// to DefaultMaxBodyBytes, unknown fields and trailing data are rejected.
+// Failures are returned as 400 or 413 HTTPErrors whose message names the
+// offending offset or field.
+func DecodeJSON(r *http.Request, v any, opts ...DecodeOption) error {
+	cfg := decodeConfig{maxBytes: DefaultMaxBodyBytes}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	body := r.Body
+	if body == nil {
+		body = http.NoBody
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(nil, body, cfg.maxBytes))
+	if !cfg.allowUnknown {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return decodeHTTPError(err)
+	}
+	if !cfg.allowTrailing {
+		if err := dec.Decode(&struct{}{}); !errors.Is(err, io.EOF) {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				return decodeHTTPError(err)
+			}
+			return HTTPError{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Request body must contain a single JSON value",
+				Cause:      err,
+			}
+		}
+	}
+	return nil
+}
+
+// MustDecodeJSON panics with the HTTPError returned by DecodeJSON
+func MustDecodeJSON(r *http.Request, v any, opts ...DecodeOption) {
+	Must(DecodeJSON(r, v, opts...))
+}
+
+// decodeHTTPError maps a JSON decoding error to an HTTPError
+func decodeHTTPError(err error) HTTPError {
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+		maxErr    *http.MaxBytesError
+	)
+
+	httpErr := HTTPError{StatusCode: http.StatusBadRequest, Cause: err}
+	switch {
+	case errors.As(err, &syntaxErr):
+		httpErr.Message = fmt.Sprintf("Invalid JSON format at offset %d", syntaxErr.Offset)
+		httpErr = httpErr.WithExtension("offset", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			httpErr.Message = fmt.Sprintf("Invalid value for field %q: expected %s", typeErr.Field, typeErr.Type)
+			httpErr = httpErr.WithExtension("field", typeErr.Field)
+		} else {
+			httpErr.Message = fmt.Sprintf("Invalid JSON value at offset %d: expected %s", typeErr.Offset, typeErr.Type)
+		}
+		httpErr = httpErr.WithExtension("offset", typeErr.Offset)
+	case errors.As(err, &maxErr):
+		httpErr.StatusCode = http.StatusRequestEntityTooLarge
+		httpErr.Message = fmt.Sprintf("Request body must not be larger than %d bytes", maxErr.Limit)
+	case errors.Is(err, io.EOF):
+		httpErr.Message = "Request body must not be empty"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		httpErr.Message = "Invalid JSON format: unexpected end of body"
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		// encoding/json has no typed error for unknown fields
+		field := strings.TrimPrefix(err.Error(), "json: unknown field ")
+		httpErr.Message = "Unknown field " + field
+		httpErr = httpErr.WithExtension("field", strings.Trim(field, `"`))
+	default:
+		httpErr.Message = "Invalid JSON format"
+	}
+	return httpErr
+}
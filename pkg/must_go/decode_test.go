@@ -0,0 +1,77 @@
+package must_go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Age  int    `json:"age"`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		opts       []DecodeOption
+		wantStatus int
+		wantMsg    string
+	}{
+		{"valid", `{"name":"John","age":30}`, nil, 0, ""},
+		{"syntax error", `{"name":"John",}`, nil, http.StatusBadRequest, "Invalid JSON format at offset 16"},
+		{"type error", `{"name":"John","age":"thirty"}`, nil, http.StatusBadRequest, `Invalid value for field "age": expected int`},
+		{"unknown field", `{"name":"John","admin":true}`, nil, http.StatusBadRequest, `Unknown field "admin"`},
+		{"unknown field allowed", `{"name":"John","admin":true}`, []DecodeOption{AllowUnknownFields()}, 0, ""},
+		{"trailing data", `{"name":"John"} {"name":"Jane"}`, nil, http.StatusBadRequest, "Request body must contain a single JSON value"},
+		{"trailing data allowed", `{"name":"John"} garbage`, []DecodeOption{AllowTrailingData()}, 0, ""},
+		{"empty", ``, nil, http.StatusBadRequest, "Request body must not be empty"},
+		{"truncated", `{"name":`, nil, http.StatusBadRequest, "Invalid JSON format: unexpected end of body"},
+		{"too large", `{"name":"` + strings.Repeat("x", 64) + `"}`, []DecodeOption{MaxBodyBytes(32)}, http.StatusRequestEntityTooLarge, "Request body must not be larger than 32 bytes"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var u user
+			err := DecodeJSON(httptest.NewRequest("POST", "/", strings.NewReader(tt.body)), &u, tt.opts...)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Expected no error, got: %v", err)
+				}
+				if u.Name != "John" {
+					t.Errorf("Expected name John, got: %s", u.Name)
+				}
+				return
+			}
+
+			httpErr, ok := err.(HTTPError)
+			if !ok {
+				t.Fatalf("Expected HTTPError, got: %T %v", err, err)
+			}
+			if httpErr.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got: %d", tt.wantStatus, httpErr.StatusCode)
+			}
+			if httpErr.Message != tt.wantMsg {
+				t.Errorf("Expected message '%s', got: '%s'", tt.wantMsg, httpErr.Message)
+			}
+		})
+	}
+}
+
+func TestMustDecodeJSON(t *testing.T) {
+	defer func() {
+		httpErr, ok := recover().(HTTPError)
+		if !ok {
+			t.Fatal("Expected HTTPError panic")
+		}
+		if httpErr.Extensions["field"] != "age" {
+			t.Errorf("Expected field extension 'age', got: %v", httpErr.Extensions["field"])
+		}
+	}()
+	var v struct {
+		Age int `json:"age"`
+	}
+	MustDecodeJSON(httptest.NewRequest("POST", "/", strings.NewReader(`{"age":true}`)), &v)
+}
@@ -0,0 +1,198 @@
+package must_go
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithDeduplication rate-limits the logging and hooks of repeated panics.
+// Within interval, counted from the first occurrence, only the first burst
+// panics with the same fingerprint are logged and passed to OnPanic,
+// AfterRender and WithNotifier hooks. The rest are collapsed into a single
+// summary at the end of the interval: a "repeated panics suppressed" log
+// entry and a call of the hooks with the last suppressed report, whose
+// Repeated field holds the number of panics it stands for.
+//
+// Each suppressed panic is still logged as a short "panic suppressed" line
+// at the level the full entry would have used, so a reference shown to a
+// client can always be found in the logs. The summary also lists the first
+// maxSuppressedIDs references and request ids of the suppressed panics.
+//
+// Responses are unaffected, and metrics, expvar statistics and panic
+// buffers still see every panic.
+func WithDeduplication(interval time.Duration, burst int) Option {
+	return func(rc *Recovery) {
+		if burst < 1 {
+			burst = 1
+		}
+		rc.dedup = &deduper{
+			rc:       rc,
+			interval: interval,
+			burst:    burst,
+			entries:  make(map[string]*dedupEntry),
+		}
+	}
+}
+
+// FlushDuplicates immediately emits the summaries of suppressed panics
+// instead of waiting for their interval to end, e.g. before shutdown
+func (rc *Recovery) FlushDuplicates() {
+	if rc.dedup != nil {
+		rc.dedup.flushAll()
+	}
+}
+
+// maxSuppressedIDs caps the references and request ids listed in a summary
+const maxSuppressedIDs = 100
+
+// deduper tracks recent panics by fingerprint
+type deduper struct {
+	rc       *Recovery
+	interval time.Duration
+	burst    int
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+// dedupEntry counts the panics of one fingerprint in the current interval
+type dedupEntry struct {
+	seen       int
+	suppressed int
+	last       PanicReport
+	req        *http.Request
+	timer      *time.Timer
+	// references and request ids of the suppressed panics, up to
+	// maxSuppressedIDs each
+	references []string
+	requestIDs []string
+}
+
+// allow records a panic and reports whether it is logged and passed to the
+// hooks, or suppressed until the summary
+func (d *deduper) allow(r *http.Request, report PanicReport) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	e, ok := d.entries[report.Fingerprint]
+	if !ok {
+		e = &dedupEntry{}
+		d.entries[report.Fingerprint] = e
+		fp := report.Fingerprint
+		e.timer = time.AfterFunc(d.interval, func() { d.flush(fp) })
+	}
+	e.seen++
+	if e.seen <= d.burst {
+		return true
+	}
+	e.suppressed++
+	e.last = report
+	if report.Reference != "" && len(e.references) < maxSuppressedIDs {
+		e.references = append(e.references, report.Reference)
+	}
+	if report.RequestID != "" && len(e.requestIDs) < maxSuppressedIDs {
+		e.requestIDs = append(e.requestIDs, report.RequestID)
+	}
+	if r != nil {
+		// The summary outlives the request
+		e.req = r.WithContext(context.WithoutCancel(r.Context()))
+	}
+	return false
+}
+
+// flush ends the interval of a fingerprint and emits its summary
+func (d *deduper) flush(fp string) {
+	d.mu.Lock()
+	e, ok := d.entries[fp]
+	delete(d.entries, fp)
+	d.mu.Unlock()
+
+	if ok {
+		e.timer.Stop()
+		d.summarize(e)
+	}
+}
+
+// flushAll ends the intervals of all fingerprints
+func (d *deduper) flushAll() {
+	d.mu.Lock()
+	entries := d.entries
+	d.entries = make(map[string]*dedupEntry)
+	d.mu.Unlock()
+
+	for _, e := range entries {
+		e.timer.Stop()
+		d.summarize(e)
+	}
+}
+
+// summarize logs and reports the panics suppressed in an interval
+func (d *deduper) summarize(e *dedupEntry) {
+	if e.suppressed == 0 {
+		return
+	}
+	report := e.last
+	report.Repeated = e.suppressed
+	logSuppressed(d.rc.log(), d.rc.clientLevel, d.interval, report, e.references, e.requestIDs)
+
+	r := e.req
+	if r == nil {
+		r = (&http.Request{Method: report.Method}).WithContext(context.Background())
+	}
+	d.rc.runHooks(d.rc.hooks, r, report)
+	d.rc.runHooks(d.rc.afterHooks, r, report)
+}
+
+// logSuppressed logs the summary of panics suppressed by deduplication
+func logSuppressed(logger *slog.Logger, clientLevel slog.Level, interval time.Duration, report PanicReport, references, requestIDs []string) {
+	level := clientLevel
+	if report.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	attrs := []slog.Attr{
+		slog.String("fingerprint", report.Fingerprint),
+		slog.Int("count", report.Repeated),
+		slog.Duration("interval", interval),
+		slog.Int("status", report.Status),
+		slog.String("method", report.Method),
+		slog.String("path", report.Path),
+		slog.String("error", report.Message),
+	}
+	if len(references) > 0 {
+		attrs = append(attrs, slog.Any("references", references))
+	}
+	if len(requestIDs) > 0 {
+		attrs = append(attrs, slog.Any("request_ids", requestIDs))
+	}
+	logger.LogAttrs(context.Background(), level, "repeated panics suppressed", attrs...)
+}
+
+// logSuppressedPanic logs a compact line for a panic suppressed by
+// deduplication, so its reference and request id stay searchable. It uses
+// the level logPanic would have used.
+func logSuppressedPanic(logger *slog.Logger, clientLevel slog.Level, r *http.Request, report PanicReport) {
+	level := clientLevel
+	if report.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	ctx := context.Background()
+	if r != nil {
+		ctx = r.Context()
+	}
+	attrs := []slog.Attr{
+		slog.String("fingerprint", report.Fingerprint),
+		slog.Int("status", report.Status),
+		slog.String("method", report.Method),
+		slog.String("path", report.Path),
+	}
+	if report.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", report.RequestID))
+	}
+	if report.Reference != "" {
+		attrs = append(attrs, slog.String("reference", report.Reference))
+	}
+	logger.LogAttrs(ctx, level, "panic suppressed", attrs...)
+}
@@ -0,0 +1,189 @@
+package must_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{"runtime error: index out of range [3] with length 0", "runtime error: index out of range [<n>] with length <n>"},
+		{`user "alice" not found`, `user "<str>" not found`},
+		{"order 123e4567-e89b-12d3-a456-426614174000 missing", "order <uuid> missing"},
+		{"bad pointer 0xc000123456 in object 5f9b2c1a7e3d", "bad pointer <hex> in object <hex>"},
+	}
+	for _, tt := range tests {
+		if got := normalizeMessage(tt.msg); got != tt.want {
+			t.Errorf("normalizeMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	stack := []Frame{{Function: "app.handler", File: "app.go", Line: 10}, {Function: "app.main", File: "main.go", Line: 3}}
+	moved := []Frame{{Function: "app.handler", File: "app.go", Line: 42}, {Function: "app.main", File: "main.go", Line: 3}}
+	other := []Frame{{Function: "app.other", File: "app.go", Line: 10}}
+
+	base := fingerprint(fmt.Errorf("load user %d: %w", 7, errNoSuchUser), stack)
+	if got := fingerprint(fmt.Errorf("load user %d: %w", 8, errNoSuchUser), moved); got != base {
+		t.Errorf("Expected ids and line numbers to be ignored, got: %s and %s", got, base)
+	}
+	if got := fingerprint(fmt.Errorf("load user %d: %w", 7, errNoSuchUser), other); got == base {
+		t.Error("Expected a different top frame to change the fingerprint")
+	}
+	if got := fingerprint(errors.New("load user 7: no such user"), stack); got == base {
+		t.Error("Expected a different error type to change the fingerprint")
+	}
+	if got := fingerprint(errors.New("save user 7"), stack); got == base {
+		t.Error("Expected a different message to change the fingerprint")
+	}
+}
+
+func TestRecoveryDeduplication(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	var mu sync.Mutex
+	var hooked []PanicReport
+	var observed int
+	buffer := NewPanicBuffer(10)
+	rc := NewRecovery(
+		WithLogger(logger),
+		WithDeduplication(time.Hour, 2),
+		WithPanicBuffer(buffer),
+		WithObserver(func(r *http.Request, report PanicReport) {
+			mu.Lock()
+			defer mu.Unlock()
+			observed++
+		}),
+		OnPanic(func(r *http.Request, report PanicReport) {
+			mu.Lock()
+			defer mu.Unlock()
+			hooked = append(hooked, report)
+		}),
+	)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var items []int
+		_ = items[len(r.URL.Path)]
+	}))
+
+	for _, target := range []string{"/a", "/bb", "/ccc", "/dddd", "/eeeee"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected every response to be a 500, got: %d", w.Code)
+		}
+	}
+
+	if n := strings.Count(buf.String(), "msg=\"panic recovered\""); n != 2 {
+		t.Errorf("Expected 2 panic logs before the summary, got: %d\n%s", n, buf.String())
+	}
+	if len(hooked) != 2 {
+		t.Errorf("Expected 2 hook calls before the summary, got: %d", len(hooked))
+	}
+	if n := len(buffer.Reports()); n != 5 {
+		t.Errorf("Expected the panic buffer to see all 5 panics, got: %d", n)
+	}
+	if observed != 5 {
+		t.Errorf("Expected observers to see all 5 panics, got: %d", observed)
+	}
+
+	rc.FlushDuplicates()
+	if !strings.Contains(buf.String(), `msg="repeated panics suppressed"`) || !strings.Contains(buf.String(), "count=3") {
+		t.Errorf("Expected a summary counting 3 panics, got:\n%s", buf.String())
+	}
+	if len(hooked) != 3 || hooked[2].Repeated != 3 || hooked[2].Path != "/eeeee" {
+		t.Errorf("Expected a summary hook call for the last of 3 panics, got: %+v", hooked)
+	}
+
+	// The next occurrence starts a new interval
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/f", nil))
+	if len(hooked) != 4 || hooked[3].Repeated != 0 {
+		t.Errorf("Expected the next panic to be reported again, got: %d hook calls", len(hooked))
+	}
+}
+
+func TestDeduplicationSummaryTimer(t *testing.T) {
+	summaries := make(chan PanicReport, 1)
+	rc := NewRecovery(
+		WithLogger(slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))),
+		WithDeduplication(20*time.Millisecond, 1),
+		AfterRender(func(r *http.Request, report PanicReport) {
+			if report.Repeated > 0 {
+				summaries <- report
+			}
+		}),
+	)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	for i := 0; i < 3; i++ {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}
+
+	select {
+	case report := <-summaries:
+		if report.Repeated != 2 {
+			t.Errorf("Expected summary of 2 panics, got: %d", report.Repeated)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected a summary when the interval ended")
+	}
+}
+
+func TestDeduplicationKeepsReferences(t *testing.T) {
+	var buf bytes.Buffer
+	rc := NewRecovery(
+		WithLogger(slog.New(slog.NewTextHandler(&buf, nil))),
+		WithDeduplication(time.Hour, 1),
+	)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Must(errors.New("connection refused"))
+	}))
+
+	// More suppressed panics than the summary lists
+	var references []string
+	for i := 0; i < maxSuppressedIDs+5; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Request-ID", fmt.Sprintf("req-%d", i))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		var body struct {
+			Error struct {
+				Reference string `json:"reference"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error.Reference == "" {
+			t.Fatalf("Expected a reference in the response, got: %s", w.Body.String())
+		}
+		references = append(references, body.Error.Reference)
+	}
+	rc.FlushDuplicates()
+	logged := buf.String()
+
+	// Every reference sent to a client can be found in the logs
+	for _, ref := range references {
+		if !strings.Contains(logged, "reference="+ref) {
+			t.Errorf("Expected reference %s to be logged", ref)
+		}
+	}
+	if n := strings.Count(logged, `level=ERROR msg="panic suppressed"`); n != maxSuppressedIDs+4 {
+		t.Errorf("Expected %d suppressed panic lines at Error level, got: %d", maxSuppressedIDs+4, n)
+	}
+	if !strings.Contains(logged, "request_ids=\"[req-1 req-2 ") {
+		t.Errorf("Expected the summary to list the suppressed request ids, got:\n%s", logged)
+	}
+}
@@ -0,0 +1,73 @@
+package must_go
+
+import (
+	"context"
+	"sync"
+)
+
+// Go runs fn in a new goroutine. Panics in fn are recovered and delivered as
+// a *PanicError on the returned channel instead of crashing the process. The
+// channel receives exactly one value, nil on success.
+//
+// Re-raise the result on the request goroutine with Must(<-ch) so that the
+// recovery middleware renders it.
+func Go(ctx context.Context, fn func(ctx context.Context) error) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		ch <- runRecovered(func() error { return fn(ctx) })
+	}()
+	return ch
+}
+
+// Group is a collection of goroutines working on subtasks of a common task,
+// like errgroup.Group, that recovers panics in its goroutines. A zero Group
+// is valid and does not cancel on error.
+type Group struct {
+	cancel context.CancelCauseFunc
+	wg     sync.WaitGroup
+
+	errOnce sync.Once
+	err     error
+}
+
+// NewGroup returns a new Group and an associated context derived from ctx.
+// The context is canceled the first time a function passed to Go returns an
+// error or panics, or when Wait returns, whichever occurs first.
+func NewGroup(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Go calls fn in a new goroutine. Panics are recovered and converted into a
+// *PanicError.
+func (g *Group) Go(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := runRecovered(fn); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(err)
+				}
+			})
+		}
+	}()
+}
+
+// Wait blocks until all function calls from Go have returned, then returns
+// the first error, if any. Use Must(g.Wait()) to re-raise it on the request
+// goroutine.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
+
+// runRecovered calls fn and converts any panic into a *PanicError
+func runRecovered(fn func() error) (err error) {
+	defer Handle(&err, RecoverAll())
+	return fn()
+}
@@ -0,0 +1,68 @@
+package must_go
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGo(t *testing.T) {
+	err := <-Go(context.Background(), func(ctx context.Context) error {
+		var m map[string]int
+		m["boom"] = 1
+		return nil
+	})
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *PanicError, got: %v", err)
+	}
+
+	if err := <-Go(context.Background(), func(ctx context.Context) error { return nil }); err != nil {
+		t.Errorf("Expected nil, got: %v", err)
+	}
+}
+
+func TestGroup(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	g.Go(func() error {
+		MustNotFound(errNoSuchUser)
+		return nil
+	})
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	err := g.Wait()
+	if StatusOf(err) != http.StatusNotFound {
+		t.Errorf("Expected the first error with status 404, got: %v", err)
+	}
+	if !errors.Is(context.Cause(ctx), errNoSuchUser) {
+		t.Errorf("Expected context to be canceled with the panic, got: %v", context.Cause(ctx))
+	}
+
+	var zero Group
+	zero.Go(func() error { return nil })
+	if err := zero.Wait(); err != nil {
+		t.Errorf("Expected nil from zero Group, got: %v", err)
+	}
+}
+
+func TestGroupReRaiseOnRequestGoroutine(t *testing.T) {
+	handler := NewRecovery().Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g, _ := NewGroup(r.Context())
+		g.Go(func() error {
+			MustConflict(errNoSuchUser)
+			return nil
+		})
+		Must(g.Wait())
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status 409, got: %d", w.Code)
+	}
+}
@@ -0,0 +1,139 @@
+package must_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+)
+
+// HandlerFunc is an HTTP handler that returns its error instead of
+// panicking. Returned errors are rendered by the Recovery the request passed
+// through, exactly like a recovered Must* panic: HTTPErrors and errors known
+// to its registry keep their status code. Without a Recovery upstream,
+// errors are resolved through DefaultRegistry.
+type HandlerFunc func(http.ResponseWriter, *http.Request) error
+
+// ServeHTTP calls f(w, r) and renders a returned error. The writer passed to
+// f tracks whether the response was committed, so an error returned after
+// writing aborts the connection instead of appending an error body, with
+// or without a Recovery.Handler upstream.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := newResponseWriter(w)
+	if err := f(rw, r); err != nil {
+		recoveryFrom(r).ServeError(rw, r, err)
+	}
+}
+
+// Validator is implemented by request types that can validate themselves.
+// HandleJSON calls Validate after decoding the request body.
+type Validator interface {
+	Validate() error
+}
+
+// JSONOption configures HandleJSON
+type JSONOption func(*jsonConfig)
+
+type jsonConfig struct {
+	successStatus int
+	decodeOptions []DecodeOption
+}
+
+// WithSuccessStatus sets the status code written when the function succeeds.
+// The default is 200 OK. With 204 No Content no body is written.
+func WithSuccessStatus(statusCode int) JSONOption {
+	return func(c *jsonConfig) {
+		c.successStatus = statusCode
+	}
+}
+
+// WithDecodeOptions sets the options used to decode the request body
+func WithDecodeOptions(opts ...DecodeOption) JSONOption {
+	return func(c *jsonConfig) {
+		c.decodeOptions = append(c.decodeOptions, opts...)
+	}
+}
+
+// HandleJSON adapts a typed function to an http.Handler. The request body is
+// decoded into Req with DecodeJSON and validated with ValidateStruct and, if
+// Req implements Validator, its Validate method. fn is called and its result
+// is encoded as JSON. Decoding and validation errors, returned errors and
+// Must* panics in fn are all rendered by the Recovery the request passed
+// through.
+func HandleJSON[Req, Resp any](fn func(ctx context.Context, req Req) (Resp, error), opts ...JSONOption) http.Handler {
+	cfg := jsonConfig{successStatus: http.StatusOK}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) (err error) {
+		defer Handle(&err)
+
+		var req Req
+		if err := decodeJSONBody(r, &req, cfg.decodeOptions); err != nil {
+			return err
+		}
+		if err := validate(&req); err != nil {
+			return err
+		}
+
+		resp, err := fn(r.Context(), req)
+		if err != nil {
+			return err
+		}
+
+		if cfg.successStatus == http.StatusNoContent {
+			w.WriteHeader(cfg.successStatus)
+			return nil
+		}
+		// Encode before writing so an unencodable response still becomes an
+		// error response instead of a truncated body
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(resp); err != nil {
+			return err
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(cfg.successStatus)
+		_, err = w.Write(buf.Bytes())
+		return err
+	})
+}
+
+// decodeJSONBody decodes the request body into v with DecodeJSON. A request
+// without a body leaves v untouched.
+func decodeJSONBody(r *http.Request, v any, opts []DecodeOption) error {
+	if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return nil
+	}
+	return DecodeJSON(r, v, opts...)
+}
+
+// validate checks the validate struct tags of v with ValidateStruct, then
+// calls Validate if v implements Validator. Validation errors without an
+// HTTPError of their own become 400 validation errors listing the field
+// errors they contain.
+func validate(v any) error {
+	if err := ValidateStruct(v); err != nil {
+		return err
+	}
+
+	validator, ok := v.(Validator)
+	if !ok {
+		validator, ok = reflect.ValueOf(v).Elem().Interface().(Validator)
+	}
+	if !ok {
+		return nil
+	}
+
+	err := validator.Validate()
+	if err == nil {
+		return nil
+	}
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+	return HTTPError{StatusCode: http.StatusBadRequest, Message: "Validation error", Cause: err, Errors: fieldErrors(err)}
+}
@@ -0,0 +1,165 @@
+package must_go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerFunc(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantBody   string
+	}{
+		{"nil", nil, http.StatusOK, "ok"},
+		{"http error", HTTPError{StatusCode: http.StatusConflict, Message: "Resource conflict"}, http.StatusConflict, "Resource conflict"},
+		{"registered error", fmt.Errorf("open avatar: %w", fs.ErrNotExist), http.StatusNotFound, "Resource not found"},
+		{"plain error", errors.New("pq: relation users does not exist"), http.StatusInternalServerError, "Internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				if tt.err != nil {
+					return tt.err
+				}
+				_, err := io.WriteString(w, "ok")
+				return err
+			})
+
+			for name, handler := range map[string]http.Handler{
+				"without middleware": h,
+				"with middleware":    NewRecovery().Handler(h),
+			} {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+				if w.Code != tt.wantStatus {
+					t.Errorf("%s: expected status %d, got: %d", name, tt.wantStatus, w.Code)
+				}
+				if !strings.Contains(w.Body.String(), tt.wantBody) {
+					t.Errorf("%s: expected body to contain %q, got: %s", name, tt.wantBody, w.Body.String())
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerFuncUsesRecoveryFromChain(t *testing.T) {
+	var report PanicReport
+	rc := NewRecovery(
+		WithProblemDetails(),
+		OnPanic(func(r *http.Request, rep PanicReport) { report = rep }),
+	)
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return HTTPError{StatusCode: http.StatusNotFound, Message: "User not found", Cause: errNoSuchUser}
+	}))
+
+	w := httptest.NewRecorder()
+	rc.Handler(mux).ServeHTTP(w, httptest.NewRequest("GET", "/users/7", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Expected Content-Type %s, got: %s", ProblemContentType, ct)
+	}
+	if !strings.Contains(w.Body.String(), `"instance":"/users/7"`) {
+		t.Errorf("Expected problem document, got: %s", w.Body.String())
+	}
+	if report.Status != http.StatusNotFound || !strings.Contains(report.Message, "no such user") {
+		t.Errorf("Expected hook to see the returned error, got: %d %s", report.Status, report.Message)
+	}
+}
+
+type createUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+func (r createUserRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type createUserResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestHandleJSON(t *testing.T) {
+	handler := NewRecovery().Handler(HandleJSON(func(ctx context.Context, req createUserRequest) (createUserResponse, error) {
+		switch req.Name {
+		case "taken":
+			MustConflict(errors.New("duplicate key"))
+		case "broken":
+			return createUserResponse{}, errors.New("insert failed")
+		}
+		return createUserResponse{ID: 1, Name: req.Name}, nil
+	}, WithSuccessStatus(http.StatusCreated)))
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"created", `{"name":"John","email":"john@example.com"}`, http.StatusCreated, `{"id":1,"name":"John"}`},
+		{"invalid json", `{"name":`, http.StatusBadRequest, "Invalid JSON format"},
+		{"validation", `{"email":"john@example.com"}`, http.StatusBadRequest, "Validation error"},
+		{"must panic", `{"name":"taken"}`, http.StatusConflict, "Resource conflict"},
+		{"returned error", `{"name":"broken"}`, http.StatusInternalServerError, "Internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("POST", "/users", strings.NewReader(tt.body)))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got: %d", tt.wantStatus, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected Content-Type application/json, got: %s", ct)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %q, got: %s", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestHandleJSONUnencodableResponse(t *testing.T) {
+	handler := NewRecovery().Handler(HandleJSON(func(ctx context.Context, req struct{}) (float64, error) {
+		return math.NaN(), nil
+	}, WithSuccessStatus(http.StatusCreated)))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/users", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got: %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "Internal server error") {
+		t.Errorf("Expected an error body, got: %s", w.Body.String())
+	}
+}
+
+func TestHandleJSONNoContent(t *testing.T) {
+	handler := HandleJSON(func(ctx context.Context, req struct{}) (struct{}, error) {
+		return struct{}{}, nil
+	}, WithSuccessStatus(http.StatusNoContent))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("DELETE", "/users/1", nil))
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("Expected empty 204, got: %d %q", w.Code, w.Body.String())
+	}
+}
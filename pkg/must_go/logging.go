@@ -0,0 +1,59 @@
+package must_go
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+)
+
+// logPanic logs a recovered panic with structured request attributes. 5xx
+// responses are logged at Error level, everything else at clientLevel.
+func logPanic(logger *slog.Logger, clientLevel slog.Level, r *http.Request, report PanicReport) {
+	level := clientLevel
+	if report.Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	ctx := context.Background()
+	var attrs []slog.Attr
+	attrs = append(attrs,
+		slog.Int("status", report.Status),
+		slog.String("method", report.Method),
+		slog.String("path", report.Path),
+	)
+	if r != nil {
+		ctx = r.Context()
+		attrs = append(attrs, slog.String("remote_addr", r.RemoteAddr))
+	}
+	if report.RequestID != "" {
+		attrs = append(attrs, slog.String("request_id", report.RequestID))
+	}
+	if report.Reference != "" {
+		attrs = append(attrs, slog.String("reference", report.Reference))
+	}
+	attrs = append(attrs, slog.String("error", report.Message))
+	if report.Fingerprint != "" {
+		attrs = append(attrs, slog.String("fingerprint", report.Fingerprint))
+	}
+	if err, ok := report.Value.(error); ok {
+		attrs = append(attrs, slog.Any("error_chain", errorChain(err)))
+	}
+	if len(report.Stack) > 0 {
+		attrs = append(attrs,
+			slog.Uint64("goroutine", report.GoroutineID),
+			slog.Any("stack", formatFrames(report.Stack)),
+		)
+	}
+
+	logger.LogAttrs(ctx, level, "panic recovered", attrs...)
+}
+
+// formatFrames formats stack frames as "function file:line" strings
+func formatFrames(stack []Frame) []string {
+	lines := make([]string, len(stack))
+	for i, f := range stack {
+		lines[i] = fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+	}
+	return lines
+}
@@ -0,0 +1,189 @@
+package must_go
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMetricsBuckets are the time-to-panic histogram buckets in seconds
+var DefaultMetricsBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// maxMetricsFingerprints caps the distinct fingerprints a Metrics collector
+// exposes. Panics with new fingerprints past the cap are counted under
+// otherFingerprint so messages with unbounded variable parts cannot grow
+// the number of series without limit.
+const maxMetricsFingerprints = 100
+
+// otherFingerprint labels panics whose fingerprint is past the cap
+const otherFingerprint = "other"
+
+// Metrics counts recovered panics and error responses and serves them in
+// the Prometheus text exposition format. Attach it to a Recovery with
+// WithMetrics and mount it as the scrape endpoint:
+//
+//	metrics := must_go.NewMetrics()
+//	handler := must_go.NewRecovery(must_go.WithMetrics(metrics)).Handler(mux)
+//	http.Handle("GET /metrics", metrics)
+type Metrics struct {
+	mu     sync.Mutex
+	counts map[panicLabels]uint64
+	// fingerprints exposed as their own series
+	fingerprints map[string]struct{}
+	buckets      []float64
+	// observations per bucket, plus one for +Inf
+	bucketCounts []uint64
+	sum          float64
+	count        uint64
+}
+
+// panicLabels are the labels of the recovered panics counter
+type panicLabels struct {
+	status      int
+	route       string
+	kind        string
+	fingerprint string
+}
+
+// NewMetrics creates a collector. The time-to-panic histogram uses buckets,
+// in seconds, or DefaultMetricsBuckets if none are given.
+func NewMetrics(buckets ...float64) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultMetricsBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	return &Metrics{
+		counts:       make(map[panicLabels]uint64),
+		fingerprints: make(map[string]struct{}),
+		buckets:      buckets,
+		bucketCounts: make([]uint64, len(buckets)+1),
+	}
+}
+
+// WithMetrics records every recovered panic and returned error in m
+func WithMetrics(m *Metrics) Option {
+	return WithObserver(func(r *http.Request, report PanicReport) {
+		m.Observe(report)
+	})
+}
+
+// Observe records a panic report. Once maxMetricsFingerprints fingerprints
+// have been seen, new ones are counted under the fingerprint "other".
+func (m *Metrics) Observe(report PanicReport) {
+	labels := panicLabels{
+		status:      report.Status,
+		route:       report.Route,
+		kind:        panicKind(report.Value),
+		fingerprint: report.Fingerprint,
+	}
+	seconds := report.Elapsed.Seconds()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.fingerprints[labels.fingerprint]; !ok {
+		if len(m.fingerprints) < maxMetricsFingerprints {
+			m.fingerprints[labels.fingerprint] = struct{}{}
+		} else {
+			labels.fingerprint = otherFingerprint
+		}
+	}
+	m.counts[labels]++
+	i := sort.SearchFloat64s(m.buckets, seconds)
+	m.bucketCounts[i]++
+	m.sum += seconds
+	m.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	labels := make([]panicLabels, 0, len(m.counts))
+	for l := range m.counts {
+		labels = append(labels, l)
+	}
+	counts := make(map[panicLabels]uint64, len(m.counts))
+	for l, n := range m.counts {
+		counts[l] = n
+	}
+	bucketCounts := append([]uint64(nil), m.bucketCounts...)
+	sum, count := m.sum, m.count
+	m.mu.Unlock()
+
+	sort.Slice(labels, func(i, j int) bool {
+		a, b := labels[i], labels[j]
+		if a.status != b.status {
+			return a.status < b.status
+		}
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return a.fingerprint < b.fingerprint
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP must_go_panics_recovered_total Recovered panics and returned errors turned into error responses.\n")
+	b.WriteString("# TYPE must_go_panics_recovered_total counter\n")
+	for _, l := range labels {
+		fmt.Fprintf(&b, "must_go_panics_recovered_total{status=\"%d\",route=\"%s\",kind=\"%s\",fingerprint=\"%s\"} %d\n",
+			l.status, escapeLabel(l.route), l.kind, l.fingerprint, counts[l])
+	}
+
+	b.WriteString("# HELP must_go_time_to_panic_seconds Time from the start of the request to the panic.\n")
+	b.WriteString("# TYPE must_go_time_to_panic_seconds histogram\n")
+	var cumulative uint64
+	for i, le := range m.buckets {
+		cumulative += bucketCounts[i]
+		fmt.Fprintf(&b, "must_go_time_to_panic_seconds_bucket{le=\"%s\"} %d\n", formatFloat(le), cumulative)
+	}
+	fmt.Fprintf(&b, "must_go_time_to_panic_seconds_bucket{le=\"+Inf\"} %d\n", count)
+	fmt.Fprintf(&b, "must_go_time_to_panic_seconds_sum %s\n", formatFloat(sum))
+	fmt.Fprintf(&b, "must_go_time_to_panic_seconds_count %d\n", count)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// panicKind classifies a panic value for the kind label: "http_error" for
+// HTTPErrors, "error" for other errors such as those passed to Must or
+// returned from a HandlerFunc, and "runtime" for runtime errors and
+// non-error values
+func panicKind(v any) string {
+	if isHTTPError(v) {
+		return "http_error"
+	}
+	err, ok := v.(error)
+	if !ok {
+		return "runtime"
+	}
+	var runtimeErr runtime.Error
+	if errors.As(err, &runtimeErr) {
+		return "runtime"
+	}
+	return "error"
+}
+
+// escapeLabel escapes a Prometheus label value
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// formatFloat formats a sample value
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,121 @@
+package must_go
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(0.1, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		MustNotFound(errNoSuchUser)
+	})
+	mux.HandleFunc("GET /crash", func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["x"] = 1
+	})
+	mux.HandleFunc("GET /fail", func(w http.ResponseWriter, r *http.Request) {
+		Must(errors.New("insert failed"))
+	})
+	handler := NewRecovery(WithMetrics(metrics)).Handler(mux)
+
+	for _, target := range []string{"/users/1", "/users/2", "/crash", "/fail"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+	metrics.Observe(PanicReport{Status: 500, Elapsed: 2 * time.Second, Fingerprint: "slow"})
+
+	w := httptest.NewRecorder()
+	metrics.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	body := w.Body.String()
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Expected Prometheus content type, got: %s", ct)
+	}
+	for _, want := range []string{
+		"# TYPE must_go_panics_recovered_total counter\n",
+		`must_go_panics_recovered_total{status="404",route="GET /users/{id}",kind="http_error",fingerprint="`,
+		`must_go_panics_recovered_total{status="500",route="GET /crash",kind="runtime",fingerprint="`,
+		`must_go_panics_recovered_total{status="500",route="GET /fail",kind="error",fingerprint="`,
+		`must_go_panics_recovered_total{status="500",route="",kind="runtime",fingerprint="slow"} 1` + "\n",
+		"# TYPE must_go_time_to_panic_seconds histogram\n",
+		`must_go_time_to_panic_seconds_bucket{le="0.1"} 4` + "\n",
+		`must_go_time_to_panic_seconds_bucket{le="1"} 4` + "\n",
+		`must_go_time_to_panic_seconds_bucket{le="+Inf"} 5` + "\n",
+		"must_go_time_to_panic_seconds_count 5\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+
+	// Both 404s share a fingerprint and are counted together
+	if !strings.Contains(body, `kind="http_error",fingerprint="`) || strings.Count(body, `status="404"`) != 1 {
+		t.Errorf("Expected a single 404 series, got:\n%s", body)
+	}
+	if !strings.Contains(body, "} 2\n") {
+		t.Errorf("Expected the 404 series to count 2, got:\n%s", body)
+	}
+}
+
+func TestMetricsFingerprintCap(t *testing.T) {
+	metrics := NewMetrics()
+	for i := 0; i < maxMetricsFingerprints+10; i++ {
+		metrics.Observe(PanicReport{Status: 500, Fingerprint: fmt.Sprintf("fp%d", i)})
+	}
+	// Fingerprints seen before the cap was reached keep their series
+	metrics.Observe(PanicReport{Status: 500, Fingerprint: "fp0"})
+
+	var b strings.Builder
+	metrics.WriteTo(&b)
+	body := b.String()
+
+	if n := strings.Count(body, "must_go_panics_recovered_total{"); n != maxMetricsFingerprints+1 {
+		t.Errorf("Expected %d series, got %d", maxMetricsFingerprints+1, n)
+	}
+	for _, want := range []string{
+		`fingerprint="other"} 10` + "\n",
+		`fingerprint="fp0"} 2` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected metrics to contain %q, got:\n%s", want, body)
+		}
+	}
+}
+
+func TestPanicKind(t *testing.T) {
+	var m map[string]int
+	runtimeErr := func() (err any) {
+		defer func() { err = recover() }()
+		m["x"] = 1
+		return nil
+	}()
+
+	tests := []struct {
+		value any
+		want  string
+	}{
+		{HTTPError{StatusCode: 404}, "http_error"},
+		{errors.New("insert failed"), "error"},
+		{fmt.Errorf("wrapped: %w", HTTPError{StatusCode: 409}), "http_error"},
+		{runtimeErr, "runtime"},
+		{"boom", "runtime"},
+		{nil, "runtime"},
+	}
+	for _, tt := range tests {
+		if got := panicKind(tt.value); got != tt.want {
+			t.Errorf("panicKind(%#v) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\"b\\c\nd"); got != `a\"b\\c\nd` {
+		t.Errorf("Unexpected escaping: %s", got)
+	}
+}
@@ -1,97 +1,67 @@
 package must_go
 
 import (
-	"encoding/json"
-	"log"
+	"errors"
 	"net/http"
 )
 
-// RecoveryMiddleware recovers from panics and returns appropriate HTTP responses
+// defaultRecovery backs RecoveryMiddleware and RecoveryMiddlewareFunc. The
+// legacy presets do not consult DefaultRegistry, so errors that are not
+// HTTPErrors keep answering 500.
+var defaultRecovery = NewRecovery(WithExposure(ExposeMessages), WithRegistry(nil))
+
+// simpleRecovery backs SimpleRecoveryMiddleware
+var simpleRecovery = NewRecovery(
+	WithRegistry(nil),
+	withResolver(func(v any) HTTPError {
+		return HTTPError{StatusCode: http.StatusInternalServerError, Message: "Internal server error"}
+	}),
+	WithRenderer(TextRenderer{}),
+)
+
+// RecoveryMiddleware recovers from panics and returns appropriate HTTP
+// responses. It uses ExposeMessages for compatibility, so the text of any
+// recovered error, including database and driver errors, is sent to the
+// client. Use NewRecovery().Handler, which only exposes HTTPError messages,
+// in production.
 func RecoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				handlePanic(w, r, err)
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
+	return defaultRecovery.Handler(next)
 }
 
 // RecoveryMiddlewareFunc is a function-based version of RecoveryMiddleware
+// and exposes error messages the same way
 func RecoveryMiddlewareFunc(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				handlePanic(w, r, err)
-			}
-		}()
-		next(w, r)
-	}
+	return defaultRecovery.HandlerFunc(next)
 }
 
-// handlePanic processes the panic and returns appropriate HTTP response
-func handlePanic(w http.ResponseWriter, r *http.Request, err interface{}) {
-	log.Printf("Panic recovered: %v", err)
-
-	// Set default values
-	statusCode := http.StatusInternalServerError
-	message := "Internal server error"
-
-	// Check if it's our custom HTTPError
-	if httpErr, ok := err.(HTTPError); ok {
-		statusCode = httpErr.StatusCode
-		message = httpErr.Message
-	} else if errStr, ok := err.(string); ok {
-		// Handle string panics
-		message = errStr
-	} else if errObj, ok := err.(error); ok {
-		// Handle error panics
-		message = errObj.Error()
+// panicHTTPError converts a recovered panic value into an HTTPError. The
+// boolean reports whether the value carried an HTTPError.
+func panicHTTPError(err interface{}) (HTTPError, bool) {
+	// Check if it's our custom HTTPError, possibly wrapped
+	var httpErr HTTPError
+	if errObj, ok := err.(error); ok && errors.As(errObj, &httpErr) {
+		return httpErr, true
 	}
 
-	// Set response headers
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	// Create error response
-	errorResponse := map[string]interface{}{
-		"error": map[string]interface{}{
-			"message": message,
-			"status":  statusCode,
-		},
+	// Set default values
+	httpErr = HTTPError{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "Internal server error",
 	}
-
-	// Encode and send response
-	if err := json.NewEncoder(w).Encode(errorResponse); err != nil {
-		log.Printf("Failed to encode error response: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	if errObj, ok := err.(error); ok {
+		httpErr.Cause = errObj
 	}
+	return httpErr, false
 }
 
-// CustomRecoveryMiddleware allows custom panic handling
+// CustomRecoveryMiddleware allows custom panic handling. If the response was
+// already committed, panicHandler still gets the value but its response is
+// discarded and the connection is aborted.
 func CustomRecoveryMiddleware(panicHandler func(http.ResponseWriter, *http.Request, interface{})) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			defer func() {
-				if err := recover(); err != nil {
-					panicHandler(w, r, err)
-				}
-			}()
-			next.ServeHTTP(w, r)
-		})
-	}
+	return NewRecovery(withPanicHandler(panicHandler), WithRegistry(nil)).Handler
 }
 
 // SimpleRecoveryMiddleware provides a simple recovery that logs and returns 500
 func SimpleRecoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				log.Printf("Panic recovered: %v", err)
-				http.Error(w, "Internal server error", http.StatusInternalServerError)
-			}
-		}()
-		next.ServeHTTP(w, r)
-	})
-} 
\ No newline at end of file
+	return simpleRecovery.Handler(next)
+}
@@ -3,12 +3,28 @@ package must_go
 import (
 	"fmt"
 	"net/http"
+	"strings"
 )
 
-// HTTPError represents an HTTP error with status code and message
+// HTTPError represents an HTTP error with status code and message.
+// Message is public and sent to clients; Cause is the underlying error and is
+// only ever logged.
+//
+// Type, Title, Detail, Instance and Extensions are the RFC 9457 problem
+// details members used when the error is rendered as application/problem+json.
+// Errors lists the individual invalid fields of a request and is public like
+// Message.
 type HTTPError struct {
 	StatusCode int
 	Message    string
+	Cause      error
+	Errors     []FieldError
+
+	Type       string
+	Title      string
+	Detail     string
+	Instance   string
+	Extensions map[string]any
 }
 
 // Error implements the error interface
@@ -16,6 +32,74 @@ func (e HTTPError) Error() string {
 	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Message)
 }
 
+// Unwrap returns the underlying cause so errors.Is and errors.As see through HTTPError
+func (e HTTPError) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether target is an HTTPError with the same status code and
+// message. HTTPError is not comparable since it gained Errors and
+// Extensions, so errors.Is relies on this to keep matching HTTPError
+// targets like it did in v1.0.0.
+func (e HTTPError) Is(target error) bool {
+	t, ok := target.(HTTPError)
+	return ok && t.StatusCode == e.StatusCode && t.Message == e.Message
+}
+
+// withCause returns a copy of the template with its cause set to err
+func (e HTTPError) withCause(err error) HTTPError {
+	e.Cause = err
+	return e
+}
+
+// WithExtension returns a copy of the error with an additional problem details
+// extension member
+func (e HTTPError) WithExtension(key string, value any) HTTPError {
+	ext := make(map[string]any, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		ext[k] = v
+	}
+	ext[key] = value
+	e.Extensions = ext
+	return e
+}
+
+// causeChain flattens an error and all of its wrapped errors into a single
+// human-readable string, outermost first.
+func causeChain(err error) string {
+	return strings.Join(errorChain(err), " <- ")
+}
+
+// errorChain returns the messages of an error and all of its wrapped errors,
+// outermost first. Levels whose message is already part of their parent's,
+// as produced by fmt.Errorf("...: %w", err), are not repeated.
+func errorChain(err error) []string {
+	var parts []string
+	var walk func(err error, parent string)
+	walk = func(err error, parent string) {
+		if err == nil {
+			return
+		}
+		msg := err.Error()
+		if parent == "" || !strings.Contains(parent, msg) {
+			parts = append(parts, msg)
+		}
+		switch x := err.(type) {
+		case HTTPError:
+			// HTTPError never repeats its cause in Error
+			walk(x.Cause, "")
+		case interface{ Unwrap() []error }:
+			for _, e := range x.Unwrap() {
+				walk(e, msg)
+			}
+		case interface{ Unwrap() error }:
+			walk(x.Unwrap(), msg)
+		}
+	}
+	walk(err, "")
+	return parts
+}
+
 // Must panics if err is not nil
 func Must(err error) {
 	if err != nil {
@@ -36,48 +120,54 @@ func MustHTTP(err error, statusCode int, message string) {
 		panic(HTTPError{
 			StatusCode: statusCode,
 			Message:    message,
+			Cause:      err,
 		})
 	}
 }
 
-// MustHTTPWithDefault panics with a default HTTP error based on common error patterns
+// MustHTTPWithDefault panics with an HTTPError resolved by DefaultRegistry.
+// Registered sentinel errors and error types are consulted first; common
+// error message patterns are only used as a last resort.
 func MustHTTPWithDefault(err error) {
-	if err != nil {
-		// Try to determine appropriate status code based on error message
-		statusCode := http.StatusInternalServerError
-		message := "Internal server error"
-		
-		errStr := err.Error()
-		
-		// Common error patterns
-		switch {
-		case contains(errStr, "not found"):
-			statusCode = http.StatusNotFound
-			message = "Resource not found"
-		case contains(errStr, "unauthorized"):
-			statusCode = http.StatusUnauthorized
-			message = "Unauthorized"
-		case contains(errStr, "forbidden"):
-			statusCode = http.StatusForbidden
-			message = "Forbidden"
-		case contains(errStr, "bad request"):
-			statusCode = http.StatusBadRequest
-			message = "Bad request"
-		case contains(errStr, "validation"):
-			statusCode = http.StatusBadRequest
-			message = "Validation error"
-		case contains(errStr, "timeout"):
-			statusCode = http.StatusRequestTimeout
-			message = "Request timeout"
-		case contains(errStr, "conflict"):
-			statusCode = http.StatusConflict
-			message = "Resource conflict"
-		}
-		
-		panic(HTTPError{
-			StatusCode: statusCode,
-			Message:    message,
-		})
+	DefaultRegistry.MustHTTP(err)
+}
+
+// heuristicHTTPError determines an HTTPError based on common error message patterns
+func heuristicHTTPError(err error) HTTPError {
+	statusCode := http.StatusInternalServerError
+	message := "Internal server error"
+
+	errStr := err.Error()
+
+	// Common error patterns
+	switch {
+	case contains(errStr, "not found"):
+		statusCode = http.StatusNotFound
+		message = "Resource not found"
+	case contains(errStr, "unauthorized"):
+		statusCode = http.StatusUnauthorized
+		message = "Unauthorized"
+	case contains(errStr, "forbidden"):
+		statusCode = http.StatusForbidden
+		message = "Forbidden"
+	case contains(errStr, "bad request"):
+		statusCode = http.StatusBadRequest
+		message = "Bad request"
+	case contains(errStr, "validation"):
+		statusCode = http.StatusBadRequest
+		message = "Validation error"
+	case contains(errStr, "timeout"):
+		statusCode = http.StatusRequestTimeout
+		message = "Request timeout"
+	case contains(errStr, "conflict"):
+		statusCode = http.StatusConflict
+		message = "Resource conflict"
+	}
+
+	return HTTPError{
+		StatusCode: statusCode,
+		Message:    message,
+		Cause:      err,
 	}
 }
 
@@ -1,9 +1,15 @@
 package must_go
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 )
 
@@ -264,4 +270,125 @@ func TestCommonHTTPHelpers(t *testing.T) {
 			tt.fn(fmt.Errorf("test error"))
 		})
 	}
-} 
\ No newline at end of file
+} 
+
+func TestHTTPErrorPreservesCause(t *testing.T) {
+	cause := fmt.Errorf("query users: %w", errNoSuchUser)
+
+	defer func() {
+		httpErr, ok := recover().(HTTPError)
+		if !ok {
+			t.Fatal("Expected HTTPError panic")
+		}
+		if httpErr.Cause != cause {
+			t.Errorf("Expected cause %v, got: %v", cause, httpErr.Cause)
+		}
+		if !errors.Is(httpErr, errNoSuchUser) {
+			t.Error("Expected errors.Is to see through HTTPError")
+		}
+		var target HTTPError
+		if !errors.As(fmt.Errorf("handler: %w", httpErr), &target) || target.StatusCode != http.StatusNotFound {
+			t.Error("Expected errors.As to find the wrapped HTTPError")
+		}
+	}()
+	MustNotFound(cause)
+}
+
+func TestCauseChain(t *testing.T) {
+	err := HTTPError{
+		StatusCode: http.StatusNotFound,
+		Message:    "Resource not found",
+		Cause:      fmt.Errorf("load user 7: %w", errNoSuchUser),
+	}
+	expected := "HTTP 404: Resource not found <- load user 7: no such user"
+	if got := causeChain(err); got != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, got)
+	}
+}
+
+func TestRecoveryMiddlewareLogsCauseOnly(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	handler := RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		MustNotFound(fmt.Errorf("secret table users_v2: %w", errNoSuchUser))
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404, got: %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "users_v2") {
+		t.Errorf("Expected cause to stay out of the response, got: %s", w.Body.String())
+	}
+	if !strings.Contains(logs.String(), "secret table users_v2: no such user") {
+		t.Errorf("Expected cause chain in logs, got: %s", logs.String())
+	}
+}
+
+func TestMustMultiValue(t *testing.T) {
+	host, port := Must2(net.SplitHostPort("localhost:8080"))
+	if host != "localhost" || port != "8080" {
+		t.Errorf("Expected localhost 8080, got: %s %s", host, port)
+	}
+
+	a, b, c := Must3(1, "two", 3.0, nil)
+	if a != 1 || b != "two" || c != 3.0 {
+		t.Errorf("Expected 1 two 3, got: %v %v %v", a, b, c)
+	}
+
+	defer func() {
+		httpErr, ok := recover().(HTTPError)
+		if !ok {
+			t.Fatal("Expected HTTPError panic")
+		}
+		if httpErr.StatusCode != http.StatusBadRequest || httpErr.Cause == nil {
+			t.Errorf("Expected 400 with cause, got: %d %v", httpErr.StatusCode, httpErr.Cause)
+		}
+	}()
+	host, port = MustHTTP2(net.SplitHostPort("localhost:80"))(http.StatusBadRequest, "Invalid address")
+	if host != "localhost" || port != "80" {
+		t.Errorf("Expected localhost 80, got: %s %s", host, port)
+	}
+	x, y, z := MustHTTP3(1, "two", 3.0, nil)(http.StatusBadRequest, "Invalid triple")
+	if x != 1 || y != "two" || z != 3.0 {
+		t.Errorf("Expected 1 two 3, got: %v %v %v", x, y, z)
+	}
+	MustHTTP2(net.SplitHostPort("no-port"))(http.StatusBadRequest, "Invalid address")
+}
+
+func TestMustOK(t *testing.T) {
+	users := map[int]string{1: "John"}
+
+	name, ok := users[1]
+	if got := MustOK(name, ok, http.StatusNotFound, "User not found"); got != "John" {
+		t.Errorf("Expected John, got: %s", got)
+	}
+
+	defer func() {
+		httpErr, ok := recover().(HTTPError)
+		if !ok {
+			t.Fatal("Expected HTTPError panic")
+		}
+		if httpErr.StatusCode != http.StatusNotFound || httpErr.Message != "User not found" {
+			t.Errorf("Expected 404 'User not found', got: %d '%s'", httpErr.StatusCode, httpErr.Message)
+		}
+	}()
+	name, ok = users[2]
+	MustOK(name, ok, http.StatusNotFound, "User not found")
+}
+
+func TestHTTPErrorIs(t *testing.T) {
+	err := fmt.Errorf("handler: %w", HTTPError{StatusCode: http.StatusNotFound, Message: "User not found", Cause: errors.New("no rows")})
+	if !errors.Is(err, HTTPError{StatusCode: http.StatusNotFound, Message: "User not found"}) {
+		t.Error("Expected errors.Is to match an HTTPError with the same status and message")
+	}
+	if errors.Is(err, HTTPError{StatusCode: http.StatusNotFound, Message: "Order not found"}) {
+		t.Error("Expected errors.Is not to match a different message")
+	}
+	if errors.Is(err, HTTPError{StatusCode: http.StatusGone, Message: "User not found"}) {
+		t.Error("Expected errors.Is not to match a different status")
+	}
+}
@@ -0,0 +1,80 @@
+// Package mustexpvar publishes must_go recovery statistics through expvar.
+// It lives in its own package because importing expvar registers the
+// /debug/vars handler on http.DefaultServeMux.
+package mustexpvar
+
+import (
+	"expvar"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Devalanx/must_go/pkg/must_go"
+)
+
+// stats are the variables published by WithExpvar
+type stats struct {
+	total           *expvar.Int
+	byStatus        *expvar.Map
+	lastTime        *expvar.String
+	lastMessage     *expvar.String
+	lastStatus      *expvar.Int
+	lastFingerprint *expvar.String
+}
+
+// WithExpvar publishes recovery statistics as the expvar map name, shown by
+// the /debug/vars handler of the expvar package:
+//
+//	total                   recovered panics and returned errors
+//	by_status               counts per response status code
+//	last_panic_time         time of the last panic in RFC 3339 format
+//	last_panic_message      message the client got for the last panic
+//	last_panic_status       response status code of the last panic
+//	last_panic_fingerprint  fingerprint of the last panic
+//
+// The message is the one rendered after the exposure policy, so errors
+// hidden from clients are not published either. Recoveries configured with
+// the same name share the map.
+func WithExpvar(name string) must_go.Option {
+	s := publish(name)
+	return must_go.WithObserver(func(r *http.Request, report must_go.PanicReport) {
+		s.observe(report)
+	})
+}
+
+// publish returns the variables of the expvar map name, publishing the map
+// or adding missing variables as needed
+func publish(name string) *stats {
+	m, ok := expvar.Get(name).(*expvar.Map)
+	if !ok {
+		m = expvar.NewMap(name)
+	}
+	return &stats{
+		total:           mapVar(m, "total", new(expvar.Int)),
+		byStatus:        mapVar(m, "by_status", new(expvar.Map).Init()),
+		lastTime:        mapVar(m, "last_panic_time", new(expvar.String)),
+		lastMessage:     mapVar(m, "last_panic_message", new(expvar.String)),
+		lastStatus:      mapVar(m, "last_panic_status", new(expvar.Int)),
+		lastFingerprint: mapVar(m, "last_panic_fingerprint", new(expvar.String)),
+	}
+}
+
+// mapVar returns the variable key of m, setting it to def if it is missing
+// or of another type
+func mapVar[T expvar.Var](m *expvar.Map, key string, def T) T {
+	if v, ok := m.Get(key).(T); ok {
+		return v
+	}
+	m.Set(key, def)
+	return def
+}
+
+// observe records a panic report
+func (s *stats) observe(report must_go.PanicReport) {
+	s.total.Add(1)
+	s.byStatus.Add(strconv.Itoa(report.Status), 1)
+	s.lastTime.Set(report.Time.Format(time.RFC3339Nano))
+	s.lastMessage.Set(report.PublicMessage)
+	s.lastStatus.Set(int64(report.Status))
+	s.lastFingerprint.Set(report.Fingerprint)
+}
@@ -0,0 +1,61 @@
+package mustexpvar
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Devalanx/must_go/pkg/must_go"
+)
+
+func TestWithExpvar(t *testing.T) {
+	notFound := must_go.NewRecovery(WithExpvar("must_go_test_recovery")).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		must_go.MustNotFound(errors.New("no such user"))
+	}))
+	// A second Recovery with the same name shares the map
+	crash := must_go.NewRecovery(WithExpvar("must_go_test_recovery")).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		must_go.Must(errors.New("dial tcp 10.0.0.5:5432: connection refused"))
+	}))
+
+	notFound.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	notFound.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	crash.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	raw := expvar.Get("must_go_test_recovery").String()
+	var stats struct {
+		Total                int            `json:"total"`
+		ByStatus             map[string]int `json:"by_status"`
+		LastPanicTime        string         `json:"last_panic_time"`
+		LastPanicMessage     string         `json:"last_panic_message"`
+		LastPanicStatus      int            `json:"last_panic_status"`
+		LastPanicFingerprint string         `json:"last_panic_fingerprint"`
+	}
+	if err := json.Unmarshal([]byte(raw), &stats); err != nil {
+		t.Fatalf("Failed to decode expvar map: %v", err)
+	}
+	if stats.Total != 3 {
+		t.Errorf("Expected total 3, got: %d", stats.Total)
+	}
+	if stats.ByStatus["404"] != 2 || stats.ByStatus["500"] != 1 {
+		t.Errorf("Unexpected per-status counts: %v", stats.ByStatus)
+	}
+	if stats.LastPanicStatus != 500 || stats.LastPanicFingerprint == "" || stats.LastPanicTime == "" {
+		t.Errorf("Unexpected last panic: %d %q at %q", stats.LastPanicStatus, stats.LastPanicFingerprint, stats.LastPanicTime)
+	}
+	if stats.LastPanicMessage != "Internal server error" {
+		t.Errorf("Expected the message shown to the client, got: %q", stats.LastPanicMessage)
+	}
+	if strings.Contains(raw, "connection refused") {
+		t.Errorf("Expected the hidden error message not to be published, got: %s", raw)
+	}
+
+	// Messages of HTTPErrors are public and published as is
+	notFound.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got := expvar.Get("must_go_test_recovery").(*expvar.Map).Get("last_panic_message").String(); got != `"Resource not found"` {
+		t.Errorf("Expected the HTTPError message, got: %s", got)
+	}
+}
@@ -0,0 +1,214 @@
+package must_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultNotifyTimeout bounds how long a notification may take
+const DefaultNotifyTimeout = 5 * time.Second
+
+// ErrNotifierFull is returned by a ChannelNotifier whose buffer is full
+var ErrNotifierFull = errors.New("must_go: notifier channel is full")
+
+// Notifier is told about recovered panics, see WithNotifier
+type Notifier interface {
+	Notify(ctx context.Context, report PanicReport) error
+}
+
+// NotifierFunc adapts a function to the Notifier interface
+type NotifierFunc func(ctx context.Context, report PanicReport) error
+
+// Notify calls f(ctx, report)
+func (f NotifierFunc) Notify(ctx context.Context, report PanicReport) error {
+	return f(ctx, report)
+}
+
+// notifyContext returns the context notifications run with. It keeps the
+// request's values but is not canceled when the client goes away.
+func notifyContext(r *http.Request) context.Context {
+	if r == nil {
+		return context.Background()
+	}
+	return context.WithoutCancel(r.Context())
+}
+
+// notifyQueueSize is the number of reports a notifier queues before
+// dropping new ones
+const notifyQueueSize = 64
+
+// notifyQueue delivers reports to a notifier from a background goroutine,
+// started with the first report
+type notifyQueue struct {
+	rc    *Recovery
+	n     Notifier
+	jobs  chan notifyJob
+	start sync.Once
+}
+
+// notifyJob is a queued report, or a flush marker if done is set
+type notifyJob struct {
+	ctx    context.Context
+	report PanicReport
+	done   chan struct{}
+}
+
+// enqueue queues a report without blocking, dropping it if the queue is full
+func (q *notifyQueue) enqueue(r *http.Request, report PanicReport) {
+	q.start.Do(func() { go q.run() })
+	ctx := notifyContext(r)
+	select {
+	case q.jobs <- notifyJob{ctx: ctx, report: report}:
+	default:
+		q.rc.log().LogAttrs(ctx, slog.LevelError, "panic notification dropped",
+			slog.String("reason", "queue full"),
+			slog.String("request_id", report.RequestID),
+		)
+	}
+}
+
+// run delivers queued reports in order
+func (q *notifyQueue) run() {
+	for job := range q.jobs {
+		if job.done != nil {
+			close(job.done)
+			continue
+		}
+		if err := q.n.Notify(job.ctx, job.report); err != nil {
+			q.rc.log().LogAttrs(job.ctx, slog.LevelError, "panic notification failed",
+				slog.Any("error", err),
+				slog.String("request_id", job.report.RequestID),
+			)
+		}
+	}
+}
+
+// flush waits until the reports queued so far have been delivered
+func (q *notifyQueue) flush() {
+	q.start.Do(func() { go q.run() })
+	done := make(chan struct{})
+	q.jobs <- notifyJob{done: done}
+	<-done
+}
+
+// WebhookNotifier posts every report as JSON to a URL
+type WebhookNotifier struct {
+	URL string
+	// Header is added to every request, e.g. for authentication
+	Header http.Header
+	// Client defaults to http.DefaultClient
+	Client *http.Client
+	// Timeout defaults to DefaultNotifyTimeout
+	Timeout time.Duration
+}
+
+// Notify implements Notifier. Responses other than 2xx are errors.
+func (n WebhookNotifier) Notify(ctx context.Context, report PanicReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	timeout := n.Timeout
+	if timeout <= 0 {
+		timeout = DefaultNotifyTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range n.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("must_go: webhook %s returned %s", n.URL, resp.Status)
+	}
+	return nil
+}
+
+// JSONLinesNotifier writes every report as a line of JSON
+type JSONLinesNotifier struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONLinesNotifier creates a notifier writing to w
+func NewJSONLinesNotifier(w io.Writer) *JSONLinesNotifier {
+	return &JSONLinesNotifier{w: w}
+}
+
+// OpenJSONLinesFile creates a notifier appending to the file at path,
+// creating it if necessary. Close the notifier to close the file.
+func OpenJSONLinesFile(path string) (*JSONLinesNotifier, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return NewJSONLinesNotifier(f), nil
+}
+
+// Notify implements Notifier
+func (n *JSONLinesNotifier) Notify(ctx context.Context, report PanicReport) error {
+	line, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	_, err = n.w.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the underlying writer if it is an io.Closer
+func (n *JSONLinesNotifier) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if c, ok := n.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// ChannelNotifier sends every report to a channel, which is mostly useful in
+// tests. Reports are dropped with ErrNotifierFull instead of blocking the
+// request when the channel is full.
+type ChannelNotifier chan PanicReport
+
+// NewChannelNotifier creates a ChannelNotifier buffering size reports
+func NewChannelNotifier(size int) ChannelNotifier {
+	return make(ChannelNotifier, size)
+}
+
+// Notify implements Notifier
+func (n ChannelNotifier) Notify(ctx context.Context, report PanicReport) error {
+	select {
+	case n <- report:
+		return nil
+	default:
+		return ErrNotifierFull
+	}
+}
@@ -0,0 +1,192 @@
+package must_go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRecoveryHookPhases(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+
+	var calls []string
+	rc := NewRecovery(
+		WithLogger(logger),
+		OnPanic(func(r *http.Request, report PanicReport) {
+			calls = append(calls, "before")
+		}),
+		OnPanic(func(r *http.Request, report PanicReport) {
+			panic("broken hook")
+		}),
+		AfterRender(func(r *http.Request, report PanicReport) {
+			calls = append(calls, "after")
+		}),
+		WithRenderer(RendererFunc(func(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+			calls = append(calls, "render")
+			w.WriteHeader(httpErr.StatusCode)
+			return nil
+		})),
+	)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		MustNotFound(errNoSuchUser)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if got := strings.Join(calls, ","); got != "before,render,after" {
+		t.Errorf("Expected before,render,after, got: %s", got)
+	}
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 despite the panicking hook, got: %d", w.Code)
+	}
+	if !strings.Contains(buf.String(), `"msg":"panic hook panicked"`) || !strings.Contains(buf.String(), `"error":"broken hook"`) {
+		t.Errorf("Expected the hook panic to be logged, got: %s", buf.String())
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	var posted PanicReport
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &posted)
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "panics.jsonl")
+	file, err := OpenJSONLinesFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ch := NewChannelNotifier(1)
+
+	rc := NewRecovery(
+		WithNotifier(WebhookNotifier{URL: server.URL}),
+		WithNotifier(file),
+		WithNotifier(ch),
+	)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/orders", nil))
+	rc.FlushNotifications()
+
+	if posted.Message != "boom" || posted.Status != http.StatusInternalServerError || contentType != "application/json" {
+		t.Errorf("Unexpected webhook payload: %+v (%s)", posted, contentType)
+	}
+
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var line PanicReport
+	if err := json.Unmarshal(data, &line); err != nil || line.Path != "/orders" || !strings.HasSuffix(string(data), "\n") {
+		t.Errorf("Unexpected JSON lines output %q: %v", data, err)
+	}
+
+	select {
+	case report := <-ch:
+		if report.Message != "boom" {
+			t.Errorf("Expected report for boom, got: %+v", report)
+		}
+	default:
+		t.Fatal("Expected a report on the channel")
+	}
+
+	// A full channel drops the report instead of blocking
+	ch <- PanicReport{}
+	if err := ch.Notify(t.Context(), PanicReport{}); err != ErrNotifierFull {
+		t.Errorf("Expected ErrNotifierFull, got: %v", err)
+	}
+}
+
+func TestSlowNotifierDoesNotDelayResponse(t *testing.T) {
+	var buf bytes.Buffer
+	var mu sync.Mutex
+	release := make(chan struct{})
+	var delivered atomic.Int32
+	rc := NewRecovery(
+		WithLogger(slog.New(slog.NewTextHandler(&lockedWriter{mu: &mu, w: &buf}, nil))),
+		WithNotifier(NotifierFunc(func(ctx context.Context, report PanicReport) error {
+			<-release
+			delivered.Add(1)
+			return nil
+		})),
+	)
+	server := httptest.NewServer(rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})))
+	defer server.Close()
+
+	start := time.Now()
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the response before the notification, took %v", elapsed)
+	}
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got: %d", resp.StatusCode)
+	}
+
+	// While the notifier is stuck, reports past the queue size are dropped
+	for i := 0; i < notifyQueueSize+1; i++ {
+		rc.ServeError(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil), errors.New("boom"))
+	}
+	mu.Lock()
+	logged := buf.String()
+	mu.Unlock()
+	if !strings.Contains(logged, `msg="panic notification dropped"`) {
+		t.Errorf("Expected a dropped notification to be logged, got:\n%s", logged)
+	}
+
+	close(release)
+	rc.FlushNotifications()
+	if n := delivered.Load(); n < 2 || n > notifyQueueSize+1 {
+		t.Errorf("Expected the queued reports to be delivered, got: %d", n)
+	}
+}
+
+// lockedWriter serializes writes from the request and notifier goroutines
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+func TestWebhookNotifierError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := WebhookNotifier{URL: server.URL}.Notify(t.Context(), PanicReport{Message: "boom"})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Expected 502 error, got: %v", err)
+	}
+}
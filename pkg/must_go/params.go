@@ -0,0 +1,218 @@
+package must_go
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UUID is a string in the canonical 8-4-4-4-12 hexadecimal UUID format.
+// Use it as the type parameter of the parameter helpers to validate ids.
+type UUID string
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// UnmarshalText implements encoding.TextUnmarshaler and validates the format
+func (u *UUID) UnmarshalText(text []byte) error {
+	if !uuidPattern.Match(text) {
+		return fmt.Errorf("invalid UUID %q", text)
+	}
+	*u = UUID(strings.ToLower(string(text)))
+	return nil
+}
+
+// MustQuery returns the query parameter name converted to T. It panics with
+// a 400 HTTPError naming the parameter if it is missing or invalid.
+//
+// T may be a string, bool, integer, float, time.Time (RFC 3339 or
+// 2006-01-02), time.Duration, UUID, any encoding.TextUnmarshaler, or a slice
+// of those. Slices accept repeated parameters and comma-separated values.
+func MustQuery[T any](r *http.Request, name string) T {
+	return mustParam[T]("query", name, r.URL.Query()[name], nil)
+}
+
+// MustQueryOr is like MustQuery but returns def if the parameter is missing
+func MustQueryOr[T any](r *http.Request, name string, def T) T {
+	return mustParam("query", name, r.URL.Query()[name], &def)
+}
+
+// MustPathValue returns the path wildcard name, as matched by
+// http.ServeMux, converted to T. See MustQuery for the supported types.
+func MustPathValue[T any](r *http.Request, name string) T {
+	return mustParam[T]("path", name, pathValues(r, name), nil)
+}
+
+// MustPathValueOr is like MustPathValue but returns def if the wildcard is empty
+func MustPathValueOr[T any](r *http.Request, name string, def T) T {
+	return mustParam("path", name, pathValues(r, name), &def)
+}
+
+// MustHeader returns the request header name converted to T. See MustQuery
+// for the supported types.
+func MustHeader[T any](r *http.Request, name string) T {
+	return mustParam[T]("header", name, r.Header.Values(name), nil)
+}
+
+// MustHeaderOr is like MustHeader but returns def if the header is missing
+func MustHeaderOr[T any](r *http.Request, name string, def T) T {
+	return mustParam("header", name, r.Header.Values(name), &def)
+}
+
+// pathValues returns the path wildcard as a single-element slice, or nil
+func pathValues(r *http.Request, name string) []string {
+	if v := r.PathValue(name); v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+// mustParam converts the raw values of a parameter to T, returning def when
+// there are none and def is not nil
+func mustParam[T any](in, name string, values []string, def *T) T {
+	var v T
+	if len(values) == 0 || (len(values) == 1 && values[0] == "") {
+		if def != nil {
+			return *def
+		}
+		panic(HTTPError{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("Missing %s parameter %q", in, name),
+			Extensions: map[string]any{"parameter": name, "in": in},
+		})
+	}
+	if err := parseParam(values, reflect.ValueOf(&v).Elem()); err != nil {
+		panic(paramHTTPError(in, name, reflect.TypeOf(v), err))
+	}
+	return v
+}
+
+// paramHTTPError builds the 400 HTTPError for an invalid parameter
+func paramHTTPError(in, name string, t reflect.Type, err error) HTTPError {
+	return HTTPError{
+		StatusCode: http.StatusBadRequest,
+		Message:    fmt.Sprintf("Invalid %s parameter %q: expected %s", in, name, describeType(t)),
+		Cause:      err,
+		Extensions: map[string]any{"parameter": name, "in": in},
+	}
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	uuidType          = reflect.TypeOf(UUID(""))
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// parseParam parses raw parameter values into dst. Slices take every value
+// and split each one on commas; other types take the first value.
+func parseParam(values []string, dst reflect.Value) error {
+	if dst.Kind() == reflect.Slice && !reflect.PointerTo(dst.Type()).Implements(textUnmarshalType) {
+		var items []string
+		for _, v := range values {
+			for _, item := range strings.Split(v, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		}
+		slice := reflect.MakeSlice(dst.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := parseScalar(item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(slice)
+		return nil
+	}
+	return parseScalar(values[0], dst)
+}
+
+// parseScalar parses a single raw value into dst
+func parseScalar(s string, dst reflect.Value) error {
+	switch dst.Type() {
+	case timeType:
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			var dateErr error
+			if t, dateErr = time.Parse(time.DateOnly, s); dateErr != nil {
+				return err
+			}
+		}
+		dst.Set(reflect.ValueOf(t))
+		return nil
+	case durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		dst.SetInt(int64(d))
+		return nil
+	}
+
+	if u, ok := dst.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch dst.Kind() {
+	case reflect.String:
+		dst.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, dst.Type().Bits())
+		if err != nil {
+			return err
+		}
+		dst.SetFloat(f)
+	default:
+		// A programming error rather than a bad request
+		panic(fmt.Errorf("must_go: unsupported parameter type %s", dst.Type()))
+	}
+	return nil
+}
+
+// describeType names the expected format of a parameter type in error messages
+func describeType(t reflect.Type) string {
+	switch t {
+	case timeType:
+		return "RFC 3339 time"
+	case durationType:
+		return "duration"
+	case uuidType:
+		return "UUID"
+	}
+	switch t.Kind() {
+	case reflect.Slice:
+		return "list of " + describeType(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	}
+	return t.String()
+}
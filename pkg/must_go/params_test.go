@@ -0,0 +1,119 @@
+package must_go
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recoverHTTPError runs fn and returns the HTTPError it panicked with
+func recoverHTTPError(t *testing.T, fn func()) (httpErr HTTPError) {
+	t.Helper()
+	defer func() {
+		v := recover()
+		if v == nil {
+			t.Fatal("Expected panic")
+		}
+		var ok bool
+		if httpErr, ok = v.(HTTPError); !ok {
+			t.Fatalf("Expected HTTPError panic, got: %T %v", v, v)
+		}
+	}()
+	fn()
+	return
+}
+
+func TestMustQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?id=42&ratio=0.5&active=true&since=2024-01-02T03:04:05Z&day=2024-01-02&ttl=1m30s&ids=1,2&ids=3&uuid=123E4567-E89B-12D3-A456-426614174000", nil)
+
+	if got := MustQuery[int](r, "id"); got != 42 {
+		t.Errorf("Expected 42, got: %d", got)
+	}
+	if got := MustQuery[float64](r, "ratio"); got != 0.5 {
+		t.Errorf("Expected 0.5, got: %v", got)
+	}
+	if got := MustQuery[bool](r, "active"); !got {
+		t.Error("Expected true")
+	}
+	if got := MustQuery[time.Time](r, "since"); !got.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("Unexpected time: %v", got)
+	}
+	if got := MustQuery[time.Time](r, "day"); !got.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected date: %v", got)
+	}
+	if got := MustQuery[time.Duration](r, "ttl"); got != 90*time.Second {
+		t.Errorf("Expected 1m30s, got: %v", got)
+	}
+	if got := MustQuery[[]int](r, "ids"); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("Expected [1 2 3], got: %v", got)
+	}
+	if got := MustQuery[UUID](r, "uuid"); got != "123e4567-e89b-12d3-a456-426614174000" {
+		t.Errorf("Unexpected UUID: %s", got)
+	}
+	if got := MustQueryOr(r, "page", 1); got != 1 {
+		t.Errorf("Expected default 1, got: %d", got)
+	}
+	if got := MustQueryOr(r, "id", 1); got != 42 {
+		t.Errorf("Expected 42 over the default, got: %d", got)
+	}
+}
+
+func TestMustQueryErrors(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?id=abc&ids=1,x&uuid=nope&empty=", nil)
+
+	tests := []struct {
+		name    string
+		fn      func()
+		wantMsg string
+	}{
+		{"missing", func() { MustQuery[int](r, "page") }, `Missing query parameter "page"`},
+		{"empty", func() { MustQuery[string](r, "empty") }, `Missing query parameter "empty"`},
+		{"invalid int", func() { MustQuery[int](r, "id") }, `Invalid query parameter "id": expected integer`},
+		{"invalid slice item", func() { MustQuery[[]int](r, "ids") }, `Invalid query parameter "ids": expected list of integer`},
+		{"invalid uuid", func() { MustQuery[UUID](r, "uuid") }, `Invalid query parameter "uuid": expected UUID`},
+		{"invalid with default", func() { MustQueryOr(r, "id", 1) }, `Invalid query parameter "id": expected integer`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpErr := recoverHTTPError(t, tt.fn)
+			if httpErr.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status 400, got: %d", httpErr.StatusCode)
+			}
+			if httpErr.Message != tt.wantMsg {
+				t.Errorf("Expected message '%s', got: '%s'", tt.wantMsg, httpErr.Message)
+			}
+			if httpErr.Extensions["in"] != "query" {
+				t.Errorf("Expected in extension 'query', got: %v", httpErr.Extensions["in"])
+			}
+		})
+	}
+}
+
+func TestMustPathValueAndHeader(t *testing.T) {
+	var id int64
+	var limit uint
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id = MustPathValue[int64](r, "id")
+		limit = MustHeaderOr[uint](r, "X-Limit", 10)
+	})
+
+	r := httptest.NewRequest("GET", "/users/7", nil)
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+	if id != 7 || limit != 10 {
+		t.Errorf("Expected id 7 and default limit 10, got: %d %d", id, limit)
+	}
+
+	r = httptest.NewRequest("GET", "/", nil)
+	r.SetPathValue("id", "x")
+	r.Header.Set("X-Limit", "-1")
+	if httpErr := recoverHTTPError(t, func() { MustPathValue[int](r, "id") }); httpErr.Message != `Invalid path parameter "id": expected integer` {
+		t.Errorf("Unexpected message: %s", httpErr.Message)
+	}
+	if httpErr := recoverHTTPError(t, func() { MustHeader[uint](r, "X-Limit") }); httpErr.Message != `Invalid header parameter "X-Limit": expected non-negative integer` {
+		t.Errorf("Unexpected message: %s", httpErr.Message)
+	}
+}
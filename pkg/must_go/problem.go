@@ -0,0 +1,81 @@
+package must_go
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ProblemContentType is the media type of RFC 9457 problem details documents
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 9457 problem details document
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Errors     []FieldError
+	Extensions map[string]any
+}
+
+// NewProblem builds the problem details document for an HTTPError. Missing
+// members are defaulted: type to "about:blank", title to the status text,
+// detail to the error message and instance to the request path. The request
+// id, if any, is added as the request_id extension member.
+func NewProblem(httpErr HTTPError, r *http.Request) Problem {
+	p := Problem{
+		Type:       httpErr.Type,
+		Title:      httpErr.Title,
+		Status:     httpErr.StatusCode,
+		Detail:     httpErr.Detail,
+		Instance:   httpErr.Instance,
+		Errors:     httpErr.Errors,
+		Extensions: httpErr.Extensions,
+	}
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Detail == "" {
+		p.Detail = httpErr.Message
+	}
+	if p.Instance == "" && r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+	if id := requestID(r); id != "" {
+		if _, ok := p.Extensions["request_id"]; !ok {
+			p.Extensions = httpErr.WithExtension("request_id", id).Extensions
+		}
+	}
+	return p
+}
+
+// MarshalJSON flattens extension members into the top-level object. The
+// standard members and the errors array always take precedence over
+// extensions of the same name.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	doc := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		doc[k] = v
+	}
+	doc["type"] = p.Type
+	doc["title"] = p.Title
+	doc["status"] = p.Status
+	if p.Detail != "" {
+		doc["detail"] = p.Detail
+	} else {
+		delete(doc, "detail")
+	}
+	if p.Instance != "" {
+		doc["instance"] = p.Instance
+	} else {
+		delete(doc, "instance")
+	}
+	if len(p.Errors) > 0 {
+		doc["errors"] = p.Errors
+	}
+	return json.Marshal(doc)
+}
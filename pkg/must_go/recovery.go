@@ -0,0 +1,491 @@
+package must_go
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"reflect"
+)
+
+// Recovery is a configurable panic recovery middleware. Create one with
+// NewRecovery and wrap handlers with Handler or HandlerFunc.
+type Recovery struct {
+	logger       *slog.Logger
+	clientLevel  slog.Level
+	renderer     Renderer
+	renderers    []mediaRenderer
+	negotiate    bool
+	contentType  string
+	captureStack bool
+	hooks        []PanicHook
+	afterHooks   []PanicHook
+	exposure     ExposurePolicy
+	passThrough  []any
+	registry     *Registry
+
+	// observers see every panic, even those suppressed by deduplication
+	observers []PanicHook
+	dedup     *deduper
+	// notifiers deliver reports in the background, see WithNotifier
+	notifiers []*notifyQueue
+
+	// resolve replaces the conversion of panic values into HTTPErrors
+	resolve func(v any) HTTPError
+	// panicHandler replaces logging and rendering entirely, see CustomRecoveryMiddleware
+	panicHandler func(http.ResponseWriter, *http.Request, interface{})
+}
+
+// Option configures a Recovery
+type Option func(*Recovery)
+
+// ExposurePolicy controls what a client gets to see of a recovered panic
+type ExposurePolicy int
+
+const (
+	// ExposePublic only shows HTTPError messages and is meant for
+	// production. Every other panic value is answered with a generic
+	// "Internal server error" and a reference id that is also logged, so
+	// reports from clients can be matched with the full error. This is the
+	// default.
+	ExposePublic ExposurePolicy = iota
+	// ExposeMessages also shows the text of recovered errors and string
+	// panics. This is what RecoveryMiddleware has always done.
+	ExposeMessages
+	// ExposeDevelopment shows the text of every panic value along with its
+	// cause chain and stack trace, as error_chain and stack members. Never
+	// use it in production.
+	ExposeDevelopment
+)
+
+// NewRecovery creates a recovery middleware configured by opts. Without
+// options it logs through slog.Default(), captures stack traces, resolves
+// errors through DefaultRegistry, only exposes HTTPError messages and
+// renders the default JSON body.
+func NewRecovery(opts ...Option) *Recovery {
+	rc := &Recovery{
+		clientLevel:  slog.LevelWarn,
+		captureStack: true,
+		exposure:     ExposePublic,
+		registry:     DefaultRegistry,
+	}
+	for _, opt := range opts {
+		opt(rc)
+	}
+	if rc.renderer == nil {
+		rc.renderer = JSONRenderer{ContentType: rc.contentType}
+	}
+	if rc.negotiate {
+		for _, mr := range []mediaRenderer{
+			{"application/json", JSONRenderer{ContentType: rc.contentType}},
+			{ProblemContentType, ProblemRenderer{}},
+			{"application/xml", XMLRenderer{}},
+			{"text/xml", XMLRenderer{}},
+			{"text/plain", TextRenderer{}},
+			{"text/html", HTMLRenderer{}},
+		} {
+			if !rc.hasMediaRenderer(mr.mediaType) {
+				rc.renderers = append(rc.renderers, mr)
+			}
+		}
+	}
+	return rc
+}
+
+// WithLogger sets the logger used for recovered panics. By default
+// slog.Default() is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(rc *Recovery) {
+		rc.logger = logger
+	}
+}
+
+// WithClientErrorLevel sets the level used to log panics that resulted in a
+// response below 500. The default is slog.LevelWarn; 5xx responses are
+// always logged at slog.LevelError.
+func WithClientErrorLevel(level slog.Level) Option {
+	return func(rc *Recovery) {
+		rc.clientLevel = level
+	}
+}
+
+// WithRenderer sets the renderer used to write error responses
+func WithRenderer(renderer Renderer) Option {
+	return func(rc *Recovery) {
+		rc.renderer = renderer
+	}
+}
+
+// WithProblemDetails renders recovered panics as RFC 9457
+// application/problem+json documents instead of the default JSON body
+func WithProblemDetails() Option {
+	return WithRenderer(ProblemRenderer{})
+}
+
+// WithContentNegotiation chooses the renderer from the request's Accept
+// header among JSON, problem+json, XML, plain text and HTML, plus any media
+// types registered with WithMediaRenderer. Requests without a matching
+// Accept header get the default renderer.
+func WithContentNegotiation() Option {
+	return func(rc *Recovery) {
+		rc.negotiate = true
+	}
+}
+
+// WithMediaRenderer registers a renderer for a media type used in content
+// negotiation. It replaces the built-in renderer for the same media type.
+func WithMediaRenderer(mediaType string, renderer Renderer) Option {
+	return func(rc *Recovery) {
+		for i, mr := range rc.renderers {
+			if mr.mediaType == mediaType {
+				rc.renderers[i].renderer = renderer
+				return
+			}
+		}
+		rc.renderers = append(rc.renderers, mediaRenderer{mediaType: mediaType, renderer: renderer})
+	}
+}
+
+// WithContentType sets the Content-Type of the built-in JSON renderer, for
+// example "application/vnd.api+json". It does not affect renderers set with
+// WithRenderer or WithMediaRenderer.
+func WithContentType(contentType string) Option {
+	return func(rc *Recovery) {
+		rc.contentType = contentType
+	}
+}
+
+// WithStackTrace enables or disables stack trace capture for panics that are
+// not HTTPError values. Capture is enabled by default.
+func WithStackTrace(enabled bool) Option {
+	return func(rc *Recovery) {
+		rc.captureStack = enabled
+	}
+}
+
+// WithExposure sets the message exposure policy
+func WithExposure(policy ExposurePolicy) Option {
+	return func(rc *Recovery) {
+		rc.exposure = policy
+	}
+}
+
+// OnPanic adds a hook that is called with the report of every recovered
+// panic before the response is written. Hooks run in the order they were
+// added; a panicking hook is logged and does not affect the others.
+func OnPanic(hook PanicHook) Option {
+	return func(rc *Recovery) {
+		rc.hooks = append(rc.hooks, hook)
+	}
+}
+
+// AfterRender adds a hook that is called with the report of every recovered
+// panic after the response is written, or before the connection is aborted
+// when the response was already committed. It runs like OnPanic hooks.
+func AfterRender(hook PanicHook) Option {
+	return func(rc *Recovery) {
+		rc.afterHooks = append(rc.afterHooks, hook)
+	}
+}
+
+// WithObserver adds a hook that is called with the report of every
+// recovered panic and returned error, including those suppressed by
+// WithDeduplication. Observers run before OnPanic hooks and suit counters
+// and buffers such as Metrics and PanicBuffer.
+func WithObserver(hook PanicHook) Option {
+	return func(rc *Recovery) {
+		rc.observers = append(rc.observers, hook)
+	}
+}
+
+// WithNotifier sends the report of every recovered panic to n after the
+// response is written. Reports are queued and delivered by a background
+// goroutine, so a slow notifier does not delay responses; when the queue of
+// notifyQueueSize reports is full, the report is dropped. Dropped reports
+// and notification errors are logged.
+func WithNotifier(n Notifier) Option {
+	return func(rc *Recovery) {
+		q := &notifyQueue{rc: rc, n: n, jobs: make(chan notifyJob, notifyQueueSize)}
+		rc.notifiers = append(rc.notifiers, q)
+		rc.afterHooks = append(rc.afterHooks, q.enqueue)
+	}
+}
+
+// FlushNotifications waits until the reports queued for the notifiers have
+// been delivered, e.g. before shutdown. Call FlushDuplicates first to
+// include the pending deduplication summaries.
+func (rc *Recovery) FlushNotifications() {
+	for _, q := range rc.notifiers {
+		q.flush()
+	}
+}
+
+// WithRegistry sets the registry used to map recovered and returned errors
+// to HTTPErrors. NewRecovery uses DefaultRegistry by default; nil disables
+// lookups, as in the RecoveryMiddleware, SimpleRecoveryMiddleware and
+// CustomRecoveryMiddleware presets.
+func WithRegistry(registry *Registry) Option {
+	return func(rc *Recovery) {
+		rc.registry = registry
+	}
+}
+
+// WithPassThrough adds panic values that are not recovered but re-panicked,
+// in addition to http.ErrAbortHandler. Errors are matched with errors.Is,
+// other values by equality.
+func WithPassThrough(values ...any) Option {
+	return func(rc *Recovery) {
+		rc.passThrough = append(rc.passThrough, values...)
+	}
+}
+
+// hasMediaRenderer reports whether a renderer is registered for mediaType
+func (rc *Recovery) hasMediaRenderer(mediaType string) bool {
+	for _, mr := range rc.renderers {
+		if mr.mediaType == mediaType {
+			return true
+		}
+	}
+	return false
+}
+
+// withResolver replaces the conversion of panic values into HTTPErrors
+func withResolver(resolve func(v any) HTTPError) Option {
+	return func(rc *Recovery) {
+		rc.resolve = resolve
+	}
+}
+
+// withPanicHandler hands recovered panics to h instead of logging and rendering them
+func withPanicHandler(h func(http.ResponseWriter, *http.Request, interface{})) Option {
+	return func(rc *Recovery) {
+		rc.panicHandler = h
+	}
+}
+
+// recoveryKey is the context key under which Handler stores its Recovery
+type recoveryKey struct{}
+
+// Handler wraps next so that panics are recovered and turned into HTTP
+// responses. The Recovery is also made available to HandlerFunc and
+// HandleJSON further down the chain so that returned errors are rendered the
+// same way.
+func (rc *Recovery) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := newResponseWriter(w)
+		r = r.WithContext(context.WithValue(r.Context(), recoveryKey{}, rc))
+		defer func() {
+			if err := recover(); err != nil {
+				rc.handle(rw, r, err, rc.captureStack)
+			}
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// HandlerFunc is a function-based version of Handler
+func (rc *Recovery) HandlerFunc(next http.HandlerFunc) http.HandlerFunc {
+	return rc.Handler(next).ServeHTTP
+}
+
+// ServeError writes the response for an error returned by a handler. It goes
+// through the same hooks, logging and rendering as a recovered panic.
+func (rc *Recovery) ServeError(w http.ResponseWriter, r *http.Request, err error) {
+	if err != nil {
+		rc.handle(newResponseWriter(w), r, err, false)
+	}
+}
+
+// recoveryFrom returns the Recovery installed by Handler, or a Recovery with
+// default options when the request did not pass through one
+func recoveryFrom(r *http.Request) *Recovery {
+	if rc, ok := r.Context().Value(recoveryKey{}).(*Recovery); ok {
+		return rc
+	}
+	return fallbackRecovery
+}
+
+// fallbackRecovery renders errors of requests that did not pass through a Recovery
+var fallbackRecovery = NewRecovery()
+
+// handle processes a recovered panic or returned error and writes the
+// configured HTTP response. If the handler already committed the response,
+// no error body is written and the connection is aborted instead.
+func (rc *Recovery) handle(w *responseWriter, r *http.Request, err interface{}, captureStack bool) {
+	if matchesPanicValue(err, http.ErrAbortHandler) {
+		panic(http.ErrAbortHandler)
+	}
+	if rc.isPassThrough(err) {
+		panic(err)
+	}
+	committed := w.committed()
+
+	if rc.panicHandler != nil {
+		if committed {
+			// The handler still sees the panic, but its response is
+			// discarded since the connection is aborted
+			rc.panicHandler(discardWriter{header: make(http.Header)}, r, err)
+			rc.abort(w, r, err)
+		}
+		rc.panicHandler(w, r, err)
+		return
+	}
+
+	report := newPanicReport(r, err, captureStack)
+	report.Elapsed = report.Time.Sub(w.start)
+	httpErr, public := rc.httpError(err)
+	report.Status = httpErr.StatusCode
+	if rc.exposure == ExposePublic && !public {
+		report.Reference = newReference()
+	}
+	response := rc.public(httpErr, public, report)
+	report.PublicMessage = response.Message
+
+	rc.runHooks(rc.observers, r, report)
+	emit := rc.dedup == nil || rc.dedup.allow(r, report)
+	if emit {
+		rc.runHooks(rc.hooks, r, report)
+		logPanic(rc.log(), rc.clientLevel, r, report)
+	} else {
+		logSuppressedPanic(rc.log(), rc.clientLevel, r, report)
+	}
+
+	if committed {
+		if emit {
+			rc.runHooks(rc.afterHooks, r, report)
+		}
+		rc.abort(w, r, err)
+	}
+	renderer := rc.renderer
+	if len(rc.renderers) > 0 {
+		w.Header().Add("Vary", "Accept")
+		renderer = negotiate(r, rc.renderers, rc.renderer)
+	}
+	if renderErr := renderer.Render(w, r, response); renderErr != nil {
+		rc.log().ErrorContext(r.Context(), "failed to encode error response",
+			slog.Any("error", renderErr),
+			slog.String("request_id", report.RequestID),
+		)
+	}
+	if emit {
+		rc.runHooks(rc.afterHooks, r, report)
+	}
+}
+
+// runHooks calls each hook with the report. A panic in a hook is logged and
+// the remaining hooks still run.
+func (rc *Recovery) runHooks(hooks []PanicHook, r *http.Request, report PanicReport) {
+	for i, hook := range hooks {
+		func() {
+			defer func() {
+				if v := recover(); v != nil {
+					rc.log().LogAttrs(r.Context(), slog.LevelError, "panic hook panicked",
+						slog.Int("hook", i),
+						slog.String("error", panicMessage(v)),
+						slog.String("request_id", report.RequestID),
+					)
+				}
+			}()
+			hook(r, report)
+		}()
+	}
+}
+
+// httpError converts a panic value or returned error into an HTTPError. The
+// boolean reports whether the message was chosen by the application, through
+// an HTTPError or a registry template, rather than defaulted.
+func (rc *Recovery) httpError(v any) (HTTPError, bool) {
+	if rc.resolve != nil {
+		return rc.resolve(v), true
+	}
+	if err, ok := v.(error); ok && rc.registry != nil {
+		if httpErr, ok := rc.registry.Lookup(err); ok {
+			return httpErr, true
+		}
+	}
+	return panicHTTPError(v)
+}
+
+// isPassThrough reports whether a panic value was configured with
+// WithPassThrough. Deliberate aborts with http.ErrAbortHandler are handled
+// separately and always re-panicked so net/http can silently abort the
+// response as intended.
+func (rc *Recovery) isPassThrough(v any) bool {
+	for _, target := range rc.passThrough {
+		if matchesPanicValue(v, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPanicValue compares a panic value against a pass-through value
+func matchesPanicValue(v, target any) bool {
+	if err, ok := v.(error); ok {
+		if targetErr, ok := target.(error); ok {
+			return errors.Is(err, targetErr)
+		}
+	}
+	t := reflect.TypeOf(v)
+	return t != nil && t == reflect.TypeOf(target) && t.Comparable() && v == target
+}
+
+// abort logs that the response was already committed and aborts the
+// connection the way net/http intends, by panicking with http.ErrAbortHandler
+func (rc *Recovery) abort(w *responseWriter, r *http.Request, err interface{}) {
+	rc.log().LogAttrs(r.Context(), slog.LevelError, "response already committed, aborting connection",
+		slog.String("error", panicMessage(err)),
+		slog.Int("status", w.status),
+		slog.Int64("bytes_written", w.written),
+		slog.Bool("hijacked", w.hijacked),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.String("request_id", requestID(r)),
+	)
+	panic(http.ErrAbortHandler)
+}
+
+// public applies the exposure policy to the HTTPError sent to the client
+func (rc *Recovery) public(httpErr HTTPError, public bool, report PanicReport) HTTPError {
+	httpErr.Cause = nil
+	switch rc.exposure {
+	case ExposePublic:
+		if report.Reference != "" {
+			httpErr = httpErr.WithExtension("reference", report.Reference)
+		}
+	case ExposeMessages:
+		if !public {
+			httpErr.Message = rawMessage(report.Value, httpErr.Message)
+		}
+	case ExposeDevelopment:
+		if !public {
+			httpErr.Message = rawMessage(report.Value, httpErr.Message)
+		}
+		if err, ok := report.Value.(error); ok {
+			httpErr = httpErr.WithExtension("error_chain", errorChain(err))
+		}
+		if len(report.Stack) > 0 {
+			httpErr = httpErr.WithExtension("stack", formatFrames(report.Stack))
+		}
+	}
+	return httpErr
+}
+
+// rawMessage returns the text of a string or error panic value, or def
+func rawMessage(v any, def string) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case error:
+		return x.Error()
+	}
+	return def
+}
+
+// log returns the configured logger or slog.Default()
+func (rc *Recovery) log() *slog.Logger {
+	if rc.logger != nil {
+		return rc.logger
+	}
+	return slog.Default()
+}
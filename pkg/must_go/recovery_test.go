@@ -0,0 +1,341 @@
+package must_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryProblemDetails(t *testing.T) {
+	handler := NewRecovery(WithProblemDetails()).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(HTTPError{
+			StatusCode: http.StatusForbidden,
+			Message:    "Not enough credit",
+			Type:       "https://example.com/probs/out-of-credit",
+			Title:      "You do not have enough credit.",
+			Extensions: map[string]any{"balance": 30, "status": "overridden"},
+		})
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/account/12345/msgs/abc", nil))
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403, got: %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ProblemContentType {
+		t.Errorf("Expected Content-Type %s, got: %s", ProblemContentType, ct)
+	}
+
+	var doc map[string]any
+	if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	expected := map[string]any{
+		"type":     "https://example.com/probs/out-of-credit",
+		"title":    "You do not have enough credit.",
+		"status":   float64(http.StatusForbidden),
+		"detail":   "Not enough credit",
+		"instance": "/account/12345/msgs/abc",
+		"balance":  float64(30),
+	}
+	for k, v := range expected {
+		if doc[k] != v {
+			t.Errorf("Expected %s=%v, got: %v", k, v, doc[k])
+		}
+	}
+}
+
+func TestNewProblemDefaults(t *testing.T) {
+	p := NewProblem(HTTPError{StatusCode: http.StatusNotFound, Message: "Resource not found"}, httptest.NewRequest("GET", "/users/1", nil))
+	if p.Type != "about:blank" {
+		t.Errorf("Expected type about:blank, got: %s", p.Type)
+	}
+	if p.Title != "Not Found" {
+		t.Errorf("Expected title 'Not Found', got: %s", p.Title)
+	}
+	if p.Instance != "/users/1" {
+		t.Errorf("Expected instance /users/1, got: %s", p.Instance)
+	}
+}
+
+func TestRecoveryStructuredLogging(t *testing.T) {
+	tests := []struct {
+		name      string
+		panicWith any
+		wantLevel string
+	}{
+		{"client error", HTTPError{StatusCode: http.StatusNotFound, Message: "Resource not found", Cause: errNoSuchUser}, "WARN"},
+		{"server error", errNoSuchUser, "ERROR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+			handler := NewRecovery(WithLogger(logger)).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(tt.panicWith)
+			}))
+
+			req := httptest.NewRequest("DELETE", "/users/7", nil)
+			req.Header.Set(RequestIDHeader, "req-123")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to decode log entry %q: %v", buf.String(), err)
+			}
+			if entry["level"] != tt.wantLevel {
+				t.Errorf("Expected level %s, got: %v", tt.wantLevel, entry["level"])
+			}
+			for k, v := range map[string]any{
+				"method":      "DELETE",
+				"path":        "/users/7",
+				"remote_addr": req.RemoteAddr,
+				"request_id":  "req-123",
+			} {
+				if entry[k] != v {
+					t.Errorf("Expected %s=%v, got: %v", k, v, entry[k])
+				}
+			}
+			chain, _ := entry["error_chain"].([]any)
+			if len(chain) == 0 || chain[len(chain)-1] != errNoSuchUser.Error() {
+				t.Errorf("Expected error chain ending in the cause, got: %v", entry["error_chain"])
+			}
+		})
+	}
+}
+
+func TestRecoveryPresets(t *testing.T) {
+	dbErr := errors.New("dial tcp db.internal:5432: connection refused")
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Must(dbErr)
+	})
+	notFound := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		MustNotFound(dbErr)
+	})
+
+	tests := []struct {
+		name        string
+		handler     http.Handler
+		wantStatus  int
+		wantType    string
+		wantBody    string
+		wantNotBody string
+	}{
+		{"default exposes messages", RecoveryMiddleware(panicking), 500, "application/json", "connection refused", ""},
+		{"options hide messages", NewRecovery().Handler(panicking), 500, "application/json", "Internal server error", "db.internal"},
+		{"simple always 500", SimpleRecoveryMiddleware(notFound), 500, "text/plain; charset=utf-8", "Internal server error\n", ""},
+		{"content type", NewRecovery(WithContentType("application/vnd.api+json")).Handler(notFound), 404, "application/vnd.api+json", "Resource not found", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got: %d", tt.wantStatus, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("Expected Content-Type %s, got: %s", tt.wantType, ct)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %q, got: %s", tt.wantBody, w.Body.String())
+			}
+			if tt.wantNotBody != "" && strings.Contains(w.Body.String(), tt.wantNotBody) {
+				t.Errorf("Expected body not to contain %q, got: %s", tt.wantNotBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRecoveryOptions(t *testing.T) {
+	var report PanicReport
+	renderer := RendererFunc(func(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+		w.WriteHeader(http.StatusTeapot)
+		return nil
+	})
+	rc := NewRecovery(
+		WithRenderer(renderer),
+		WithStackTrace(false),
+		OnPanic(func(r *http.Request, rep PanicReport) { report = rep }),
+	)
+
+	w := httptest.NewRecorder()
+	rc.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusTeapot {
+		t.Errorf("Expected custom renderer status 418, got: %d", w.Code)
+	}
+	if report.Message != "boom" {
+		t.Errorf("Expected hook to see 'boom', got: %q", report.Message)
+	}
+	if len(report.Stack) != 0 {
+		t.Errorf("Expected no stack with WithStackTrace(false), got %d frames", len(report.Stack))
+	}
+}
+
+func TestCustomRecoveryMiddleware(t *testing.T) {
+	var recovered interface{}
+	handler := CustomRecoveryMiddleware(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		recovered = err
+		w.WriteHeader(http.StatusBadGateway)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("custom")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if recovered != "custom" || w.Code != http.StatusBadGateway {
+		t.Errorf("Expected custom handler to run, got %v with status %d", recovered, w.Code)
+	}
+}
+
+func TestCustomRecoveryMiddlewareMustError(t *testing.T) {
+	var recovered interface{}
+	handler := CustomRecoveryMiddleware(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		recovered = err
+		w.WriteHeader(http.StatusBadGateway)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Must(io.EOF)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if recovered != io.EOF {
+		t.Errorf("Expected custom handler to receive io.EOF, got %#v", recovered)
+	}
+}
+
+func TestRecoveryPassThrough(t *testing.T) {
+	errShutdown := errors.New("shutting down")
+	type sentinel struct{ name string }
+
+	middlewares := map[string]func(http.Handler) http.Handler{
+		"RecoveryMiddleware":       RecoveryMiddleware,
+		"SimpleRecoveryMiddleware": SimpleRecoveryMiddleware,
+		"CustomRecoveryMiddleware": CustomRecoveryMiddleware(func(http.ResponseWriter, *http.Request, interface{}) {}),
+		"RecoveryMiddlewareFunc": func(next http.Handler) http.Handler {
+			return RecoveryMiddlewareFunc(next.ServeHTTP)
+		},
+		"NewRecovery": NewRecovery(WithPassThrough(errShutdown, sentinel{"stop"})).Handler,
+	}
+
+	tests := []struct {
+		name       string
+		middleware string
+		value      any
+	}{
+		{"abort handler", "RecoveryMiddleware", http.ErrAbortHandler},
+		{"abort handler", "SimpleRecoveryMiddleware", http.ErrAbortHandler},
+		{"abort handler", "CustomRecoveryMiddleware", http.ErrAbortHandler},
+		{"abort handler", "RecoveryMiddlewareFunc", http.ErrAbortHandler},
+		{"abort handler", "NewRecovery", http.ErrAbortHandler},
+		{"wrapped error", "NewRecovery", fmt.Errorf("worker: %w", errShutdown)},
+		{"comparable value", "NewRecovery", sentinel{"stop"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.middleware+"/"+tt.name, func(t *testing.T) {
+			handler := middlewares[tt.middleware](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic(tt.value)
+			}))
+			defer func() {
+				if r := recover(); r != tt.value {
+					t.Errorf("Expected %v to be re-panicked, got: %v", tt.value, r)
+				}
+			}()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		})
+	}
+
+	// Errors passed to Must are re-panicked as is
+	t.Run("NewRecovery/must error", func(t *testing.T) {
+		handler := middlewares["NewRecovery"](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Must(errShutdown)
+		}))
+		defer func() {
+			if r := recover(); r != errShutdown {
+				t.Errorf("Expected %v to be re-panicked, got: %#v", errShutdown, r)
+			}
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	})
+
+	// Values that are not configured are still recovered
+	w := httptest.NewRecorder()
+	middlewares["NewRecovery"](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(sentinel{"other"})
+	})).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got: %d", w.Code)
+	}
+}
+
+func TestRecoveryExposurePolicy(t *testing.T) {
+	dbErr := fmt.Errorf("load user: %w", errors.New("dial tcp db.internal:5432: connection refused"))
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Must(dbErr)
+	})
+
+	tests := []struct {
+		name          string
+		policy        ExposurePolicy
+		handler       http.Handler
+		wantMessage   string
+		wantReference bool
+		wantDebug     bool
+	}{
+		{"public hides errors", ExposePublic, panicking, "Internal server error", true, false},
+		{"public shows HTTPError", ExposePublic, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			MustNotFound(dbErr)
+		}), "Resource not found", false, false},
+		{"messages", ExposeMessages, panicking, dbErr.Error(), false, false},
+		{"development", ExposeDevelopment, panicking, dbErr.Error(), false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(slog.NewJSONHandler(&buf, nil))
+			w := httptest.NewRecorder()
+			NewRecovery(WithLogger(logger), WithExposure(tt.policy)).Handler(tt.handler).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+			var body struct {
+				Error struct {
+					Message    string   `json:"message"`
+					Reference  string   `json:"reference"`
+					ErrorChain []string `json:"error_chain"`
+					Stack      []string `json:"stack"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Failed to decode body %q: %v", w.Body.String(), err)
+			}
+			if body.Error.Message != tt.wantMessage {
+				t.Errorf("Expected message %q, got: %q", tt.wantMessage, body.Error.Message)
+			}
+			if (body.Error.Reference != "") != tt.wantReference {
+				t.Errorf("Expected reference=%v, got: %q", tt.wantReference, body.Error.Reference)
+			}
+			if tt.wantReference && !strings.Contains(buf.String(), `"reference":"`+body.Error.Reference+`"`) {
+				t.Errorf("Expected reference %s in log, got: %s", body.Error.Reference, buf.String())
+			}
+			if tt.wantDebug && (len(body.Error.ErrorChain) == 0 || len(body.Error.Stack) == 0) {
+				t.Errorf("Expected error chain and stack, got: %+v", body.Error)
+			}
+			if !tt.wantDebug && (body.Error.ErrorChain != nil || body.Error.Stack != nil) {
+				t.Errorf("Expected no debug members, got: %s", w.Body.String())
+			}
+		})
+	}
+}
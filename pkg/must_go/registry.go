@@ -0,0 +1,131 @@
+package must_go
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Registry maps errors to HTTPError templates.
+//
+// Errors are resolved in the following order of precedence:
+//
+//  1. an HTTPError already present in the error chain
+//  2. sentinel errors registered with Register, matched with errors.Is
+//  3. error types registered with RegisterType, matched with errors.As
+//  4. the message heuristics used by MustHTTPWithDefault (Resolve only)
+//
+// Within the same kind of rule the most recently registered rule wins, so
+// callers can override the defaults installed on DefaultRegistry.
+type Registry struct {
+	mu        sync.RWMutex
+	sentinels []sentinelRule
+	types     []typeRule
+}
+
+type sentinelRule struct {
+	target error
+	tmpl   HTTPError
+}
+
+type typeRule struct {
+	match func(error) bool
+	tmpl  HTTPError
+}
+
+// DefaultRegistry is the registry consulted by MustHTTPWithDefault and
+// MustParseHTTPDefault.
+var DefaultRegistry = newDefaultRegistry()
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func newDefaultRegistry() *Registry {
+	r := NewRegistry()
+	r.Register(fs.ErrNotExist, HTTPError{StatusCode: http.StatusNotFound, Message: "Resource not found"})
+	r.Register(fs.ErrPermission, HTTPError{StatusCode: http.StatusForbidden, Message: "Forbidden"})
+	r.Register(context.DeadlineExceeded, HTTPError{StatusCode: http.StatusRequestTimeout, Message: "Request timeout"})
+	return r
+}
+
+// Register maps a sentinel error to an HTTPError template. Registering the
+// same sentinel again replaces its template.
+func (r *Registry) Register(target error, tmpl HTTPError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	comparable := target != nil && reflect.TypeOf(target).Comparable()
+	for i, rule := range r.sentinels {
+		// Like errors.Is, only compare targets whose type is comparable
+		if comparable && reflect.TypeOf(rule.target) == reflect.TypeOf(target) && rule.target == target {
+			r.sentinels = append(r.sentinels[:i], r.sentinels[i+1:]...)
+			break
+		}
+	}
+	r.sentinels = append(r.sentinels, sentinelRule{target: target, tmpl: tmpl})
+}
+
+// RegisterType maps every error of type T in the chain to an HTTPError template
+func RegisterType[T error](r *Registry, tmpl HTTPError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types = append(r.types, typeRule{
+		match: func(err error) bool {
+			var target T
+			return errors.As(err, &target)
+		},
+		tmpl: tmpl,
+	})
+}
+
+// Lookup returns the HTTPError for err based on error identity only. Templates
+// are returned with err as their Cause.
+func (r *Registry) Lookup(err error) (HTTPError, bool) {
+	if err == nil {
+		return HTTPError{}, false
+	}
+
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr, true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.sentinels) - 1; i >= 0; i-- {
+		if errors.Is(err, r.sentinels[i].target) {
+			return r.sentinels[i].tmpl.withCause(err), true
+		}
+	}
+	for i := len(r.types) - 1; i >= 0; i-- {
+		if r.types[i].match(err) {
+			return r.types[i].tmpl.withCause(err), true
+		}
+	}
+	return HTTPError{}, false
+}
+
+// Resolve returns the HTTPError for err, falling back to message heuristics
+// and finally to a 500 when no rule matches.
+func (r *Registry) Resolve(err error) HTTPError {
+	if httpErr, ok := r.Lookup(err); ok {
+		return httpErr
+	}
+	return heuristicHTTPError(err)
+}
+
+// MustHTTP panics with the HTTPError resolved by the registry if err is not nil
+func (r *Registry) MustHTTP(err error) {
+	if err != nil {
+		panic(r.Resolve(err))
+	}
+}
+
+// RegisterError maps a sentinel error to an HTTPError template on DefaultRegistry
+func RegisterError(target error, tmpl HTTPError) {
+	DefaultRegistry.Register(target, tmpl)
+}
@@ -0,0 +1,149 @@
+package must_go
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var errNoSuchUser = errors.New("no such user")
+
+type quotaError struct {
+	Limit int
+}
+
+func (e *quotaError) Error() string {
+	return fmt.Sprintf("quota of %d exceeded", e.Limit)
+}
+
+func TestRegistryLookup(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(errNoSuchUser, HTTPError{StatusCode: http.StatusNotFound, Message: "User not found"})
+	RegisterType[*quotaError](reg, HTTPError{StatusCode: http.StatusTooManyRequests, Message: "Quota exceeded"})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantOK     bool
+		wantStatus int
+	}{
+		{"sentinel", errNoSuchUser, true, http.StatusNotFound},
+		{"wrapped sentinel", fmt.Errorf("load profile: %w", errNoSuchUser), true, http.StatusNotFound},
+		{"type", fmt.Errorf("upload: %w", &quotaError{Limit: 10}), true, http.StatusTooManyRequests},
+		{"http error in chain", fmt.Errorf("wrap: %w", HTTPError{StatusCode: http.StatusGone, Message: "Gone"}), true, http.StatusGone},
+		{"unregistered", errors.New("user not found"), false, 0},
+		{"nil", nil, false, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpErr, ok := reg.Lookup(tt.err)
+			if ok != tt.wantOK {
+				t.Fatalf("Expected ok=%v, got: %v", tt.wantOK, ok)
+			}
+			if httpErr.StatusCode != tt.wantStatus {
+				t.Errorf("Expected status %d, got: %d", tt.wantStatus, httpErr.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRegistryPrecedence(t *testing.T) {
+	reg := NewRegistry()
+	RegisterType[*quotaError](reg, HTTPError{StatusCode: http.StatusTooManyRequests, Message: "Quota exceeded"})
+	reg.Register(errNoSuchUser, HTTPError{StatusCode: http.StatusNotFound, Message: "User not found"})
+
+	// Sentinels win over types regardless of registration order
+	err := errors.Join(&quotaError{Limit: 1}, errNoSuchUser)
+	if got := reg.Resolve(err).StatusCode; got != http.StatusNotFound {
+		t.Errorf("Expected sentinel to win with 404, got: %d", got)
+	}
+
+	// Registering the same sentinel again replaces its template
+	reg.Register(errNoSuchUser, HTTPError{StatusCode: http.StatusGone, Message: "User deleted"})
+	if got := reg.Resolve(errNoSuchUser).StatusCode; got != http.StatusGone {
+		t.Errorf("Expected re-registered 410, got: %d", got)
+	}
+
+	// Error identity wins over message heuristics
+	err = fmt.Errorf("timeout while checking quota: %w", &quotaError{Limit: 1})
+	if got := reg.Resolve(err).StatusCode; got != http.StatusTooManyRequests {
+		t.Errorf("Expected registered type to win with 429, got: %d", got)
+	}
+}
+
+func TestRegistryResolveFallsBackToHeuristics(t *testing.T) {
+	reg := NewRegistry()
+	if got := reg.Resolve(errors.New("user not found")).StatusCode; got != http.StatusNotFound {
+		t.Errorf("Expected heuristic 404, got: %d", got)
+	}
+	if got := reg.Resolve(errors.New("boom")).StatusCode; got != http.StatusInternalServerError {
+		t.Errorf("Expected 500, got: %d", got)
+	}
+}
+
+func TestMustHTTPWithDefaultUsesRegistry(t *testing.T) {
+	defer func() {
+		httpErr, ok := recover().(HTTPError)
+		if !ok {
+			t.Fatal("Expected HTTPError panic")
+		}
+		if httpErr.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected status 404, got: %d", httpErr.StatusCode)
+		}
+	}()
+	// The message contains no heuristic keyword, only the wrapped sentinel identifies it
+	MustHTTPWithDefault(fmt.Errorf("open config: %w", fs.ErrNotExist))
+}
+
+// multiError is not comparable because it holds a slice
+type multiError struct {
+	errs []error
+}
+
+func (e multiError) Error() string {
+	return fmt.Sprintf("%d errors", len(e.errs))
+}
+
+func TestRegistryRegisterNonComparable(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(multiError{errs: []error{errNoSuchUser}}, HTTPError{StatusCode: http.StatusBadRequest, Message: "Bad request"})
+	// Must not panic comparing two values of the same non-comparable type
+	reg.Register(multiError{}, HTTPError{StatusCode: http.StatusConflict, Message: "Conflict"})
+	reg.Register(errNoSuchUser, HTTPError{StatusCode: http.StatusNotFound, Message: "User not found"})
+
+	if got := reg.Resolve(errNoSuchUser).StatusCode; got != http.StatusNotFound {
+		t.Errorf("Expected 404, got: %d", got)
+	}
+}
+
+func TestRecoveryRegistryOptIn(t *testing.T) {
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Must(fmt.Errorf("read config: %w", fs.ErrNotExist))
+	})
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		wantStatus int
+	}{
+		{"NewRecovery", NewRecovery().Handler(panicking), http.StatusNotFound},
+		{"RecoveryMiddleware", RecoveryMiddleware(panicking), http.StatusInternalServerError},
+		{"RecoveryMiddlewareFunc", RecoveryMiddlewareFunc(panicking), http.StatusInternalServerError},
+		{"SimpleRecoveryMiddleware", SimpleRecoveryMiddleware(panicking), http.StatusInternalServerError},
+		{"HandlerFunc", HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			return fmt.Errorf("read config: %w", fs.ErrNotExist)
+		}), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("Expected status %d, got: %d", tt.wantStatus, w.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package must_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"html/template"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Renderer writes the error response for a recovered panic. httpErr only
+// carries what may be shown to the client; its Message has already been
+// subjected to the exposure policy of the Recovery.
+type Renderer interface {
+	Render(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error
+}
+
+// RendererFunc adapts a function to the Renderer interface
+type RendererFunc func(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error
+
+// Render calls f(w, r, httpErr)
+func (f RendererFunc) Render(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+	return f(w, r, httpErr)
+}
+
+// JSONRenderer writes the default {"error":{"message","status"}} body.
+// Extension members of the HTTPError are added to the error object.
+type JSONRenderer struct {
+	// ContentType defaults to application/json
+	ContentType string
+}
+
+// Render implements Renderer
+func (j JSONRenderer) Render(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+	contentType := j.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+
+	// Set response headers
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(httpErr.StatusCode)
+
+	// Create error response
+	errorObj := make(map[string]interface{}, len(httpErr.Extensions)+2)
+	for k, v := range httpErr.Extensions {
+		errorObj[k] = v
+	}
+	errorObj["message"] = httpErr.Message
+	errorObj["status"] = httpErr.StatusCode
+	if len(httpErr.Errors) > 0 {
+		errorObj["errors"] = httpErr.Errors
+	}
+	if id := requestID(r); id != "" {
+		errorObj["request_id"] = id
+	}
+	errorResponse := map[string]interface{}{
+		"error": errorObj,
+	}
+
+	// Encode and send response
+	return json.NewEncoder(w).Encode(errorResponse)
+}
+
+// ProblemRenderer writes RFC 9457 application/problem+json documents
+type ProblemRenderer struct{}
+
+// Render implements Renderer
+func (ProblemRenderer) Render(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(httpErr.StatusCode)
+
+	return json.NewEncoder(w).Encode(NewProblem(httpErr, r))
+}
+
+// TextRenderer writes the error message as text/plain like http.Error
+type TextRenderer struct{}
+
+// Render implements Renderer
+func (TextRenderer) Render(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+	msg := httpErr.Message
+	for _, fe := range httpErr.Errors {
+		msg += "\n" + fe.Field + ": " + fe.Message
+	}
+	if ref := reference(httpErr); ref != "" {
+		msg += "\nReference: " + ref
+	}
+	http.Error(w, msg, httpErr.StatusCode)
+	return nil
+}
+
+// XMLRenderer writes the error as <error><status/><message/></error>
+type XMLRenderer struct{}
+
+// xmlError is the XML representation of an error response
+type xmlError struct {
+	XMLName   xml.Name     `xml:"error"`
+	Status    int          `xml:"status"`
+	Message   string       `xml:"message"`
+	Type      string       `xml:"type,omitempty"`
+	Title     string       `xml:"title,omitempty"`
+	Detail    string       `xml:"detail,omitempty"`
+	RequestID string       `xml:"request_id,omitempty"`
+	Reference string       `xml:"reference,omitempty"`
+	Errors    []FieldError `xml:"errors>error,omitempty"`
+}
+
+// Render implements Renderer
+func (XMLRenderer) Render(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(httpErr.StatusCode)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(xmlError{
+		Status:    httpErr.StatusCode,
+		Message:   httpErr.Message,
+		Type:      httpErr.Type,
+		Title:     httpErr.Title,
+		Detail:    httpErr.Detail,
+		RequestID: requestID(r),
+		Reference: reference(httpErr),
+		Errors:    httpErr.Errors,
+	})
+}
+
+// defaultHTMLTemplate is used by HTMLRenderer when no template is set
+var defaultHTMLTemplate = template.Must(template.New("error").Funcs(template.FuncMap{
+	"statusText": http.StatusText,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head><title>{{.StatusCode}} {{statusText .StatusCode}}</title></head>
+<body>
+<h1>{{.StatusCode}} {{statusText .StatusCode}}</h1>
+<p>{{.Message}}</p>
+{{- with .Detail}}
+<p>{{.}}</p>
+{{- end}}
+{{- with index .Extensions "reference"}}
+<p>Reference: {{.}}</p>
+{{- end}}
+{{- with .Errors}}
+<ul>
+{{- range .}}
+<li>{{.Field}}: {{.Message}}</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- with index .Extensions "stack"}}
+<pre>
+{{- range .}}
+{{.}}
+{{- end}}
+</pre>
+{{- end}}
+</body>
+</html>
+`))
+
+// HTMLRenderer writes the error as an HTML page
+type HTMLRenderer struct {
+	// Template is executed with the HTTPError as data. A minimal page is
+	// used when it is nil.
+	Template *template.Template
+}
+
+// Render implements Renderer
+func (h HTMLRenderer) Render(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+	tmpl := h.Template
+	if tmpl == nil {
+		tmpl = defaultHTMLTemplate
+	}
+
+	// Render into a buffer first so a template error does not leave a
+	// half-written page behind
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, httpErr); err != nil {
+		http.Error(w, httpErr.Message, httpErr.StatusCode)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(httpErr.StatusCode)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// reference returns the reference id added by the ExposePublic policy
+func reference(httpErr HTTPError) string {
+	ref, _ := httpErr.Extensions["reference"].(string)
+	return ref
+}
+
+// mediaRenderer is a renderer registered for content negotiation
+type mediaRenderer struct {
+	mediaType string
+	renderer  Renderer
+}
+
+// acceptRange is a single media range of an Accept header
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses an Accept header into media ranges
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, q: q})
+	}
+	return ranges
+}
+
+// matchAccept returns the quality of mediaType under the given ranges and
+// how specific the best matching range is: 0 for */*, 1 for type/*, 2 for
+// an exact match.
+func matchAccept(ranges []acceptRange, mediaType string) (q float64, specificity int) {
+	specificity = -1
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	for _, ar := range ranges {
+		s := -1
+		switch {
+		case ar.mediaType == mediaType:
+			s = 2
+		case ar.mediaType == mainType+"/*":
+			s = 1
+		case ar.mediaType == "*/*":
+			s = 0
+		}
+		// The most specific matching range determines the quality
+		if s > specificity {
+			specificity, q = s, ar.q
+		}
+	}
+	return q, specificity
+}
+
+// negotiate picks the renderer for the request's Accept header. The default
+// renderer is used when the header is missing, accepts anything equally or
+// matches none of the registered media types.
+func negotiate(r *http.Request, renderers []mediaRenderer, fallback Renderer) Renderer {
+	header := r.Header.Get("Accept")
+	if header == "" || len(renderers) == 0 {
+		return fallback
+	}
+	ranges := parseAccept(header)
+
+	best, bestQ, bestSpecificity := fallback, 0.0, 0
+	for _, mr := range renderers {
+		q, specificity := matchAccept(ranges, mr.mediaType)
+		if q <= 0 || specificity <= 0 {
+			continue
+		}
+		if q > bestQ || (q == bestQ && specificity > bestSpecificity) {
+			best, bestQ, bestSpecificity = mr.renderer, q, specificity
+		}
+	}
+	return best
+}
@@ -0,0 +1,78 @@
+package must_go
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryContentNegotiation(t *testing.T) {
+	csv := RendererFunc(func(w http.ResponseWriter, r *http.Request, httpErr HTTPError) error {
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(httpErr.StatusCode)
+		_, err := w.Write([]byte("status,message\n404,Resource not found\n"))
+		return err
+	})
+	rc := NewRecovery(WithContentNegotiation(), WithMediaRenderer("text/csv", csv))
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		MustNotFound(errNoSuchUser)
+	}))
+
+	tests := []struct {
+		accept   string
+		wantType string
+		wantBody string
+	}{
+		{"", "application/json", `"message":"Resource not found"`},
+		{"*/*", "application/json", `"message":"Resource not found"`},
+		{"application/json", "application/json", `"status":404`},
+		{"application/problem+json", ProblemContentType, `"title":"Not Found"`},
+		{"application/xml", "application/xml; charset=utf-8", "<status>404</status>"},
+		{"text/plain", "text/plain; charset=utf-8", "Resource not found\n"},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html; charset=utf-8", "<h1>404 Not Found</h1>"},
+		{"application/xml;q=0.5, application/json;q=0.9", "application/json", `"status":404`},
+		{"text/csv", "text/csv", "404,Resource not found"},
+		{"image/png", "application/json", `"status":404`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			if w.Code != http.StatusNotFound {
+				t.Errorf("Expected status 404, got: %d", w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != tt.wantType {
+				t.Errorf("Expected Content-Type %s, got: %s", tt.wantType, ct)
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Errorf("Expected body to contain %q, got: %s", tt.wantBody, w.Body.String())
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Errorf("Expected Vary: Accept, got: %q", w.Header().Get("Vary"))
+			}
+		})
+	}
+}
+
+func TestHTMLRendererTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(`<p class="error">{{.Message}}</p>`))
+	w := httptest.NewRecorder()
+	err := HTMLRenderer{Template: tmpl}.Render(w, httptest.NewRequest("GET", "/", nil), HTTPError{
+		StatusCode: http.StatusBadRequest,
+		Message:    "<script>",
+	})
+	if err != nil {
+		t.Fatalf("Render failed: %v", err)
+	}
+	if w.Body.String() != `<p class="error">&lt;script&gt;</p>` {
+		t.Errorf("Expected escaped message, got: %s", w.Body.String())
+	}
+}
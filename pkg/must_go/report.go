@@ -0,0 +1,218 @@
+package must_go
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PanicReport describes a recovered panic. It is passed to panic hooks and
+// used for logging. RequestID is set when the request carries one, see
+// RequestIDMiddleware. Reference is the id shown to the client in place of a
+// hidden error message, see ExposePublic, and PublicMessage the message the
+// client got after the exposure policy was applied. Route is the http.ServeMux pattern
+// that matched the request and Elapsed the time from the start of the
+// request to the panic. Repeated is only set on the summaries produced by
+// WithDeduplication and counts the panics they stand for.
+type PanicReport struct {
+	Value         any           `json:"-"`
+	Message       string        `json:"message"`
+	PublicMessage string        `json:"public_message,omitempty"`
+	Status        int           `json:"status"`
+	Stack         []Frame       `json:"stack,omitempty"`
+	Method        string        `json:"method"`
+	Path          string        `json:"path"`
+	Route         string        `json:"route,omitempty"`
+	RequestID     string        `json:"request_id,omitempty"`
+	Reference     string        `json:"reference,omitempty"`
+	Fingerprint   string        `json:"fingerprint"`
+	Repeated      int           `json:"repeated,omitempty"`
+	Time          time.Time     `json:"time"`
+	Elapsed       time.Duration `json:"elapsed"`
+	GoroutineID   uint64        `json:"goroutine_id"`
+}
+
+// Frame is a single stack frame of a PanicReport
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// String formats the frame like a line of a runtime stack trace
+func (f Frame) String() string {
+	return fmt.Sprintf("%s\n\t%s:%d", f.Function, f.File, f.Line)
+}
+
+// PanicHook is called with every recovered panic
+type PanicHook func(r *http.Request, report PanicReport)
+
+// pkgPrefix is the function name prefix of everything in this package
+var pkgPrefix = reflect.TypeOf(HTTPError{}).PkgPath() + "."
+
+// trimmedPrefixes are the function name prefixes of frames that are removed
+// from captured stacks: the panic machinery, this package and net/http.
+var trimmedPrefixes = []string{"runtime.", pkgPrefix, "net/http."}
+
+// newPanicReport builds the report for a recovered panic value. It must be
+// called from the deferred function that recovered the panic for the stack
+// trace to include the panicking frames.
+func newPanicReport(r *http.Request, v any, captureStack bool) PanicReport {
+	report := PanicReport{
+		Value:       v,
+		Message:     panicMessage(v),
+		Time:        time.Now(),
+		GoroutineID: goroutineID(),
+	}
+	if r != nil {
+		report.Method = r.Method
+		if r.URL != nil {
+			report.Path = r.URL.Path
+		}
+		report.Route = r.Pattern
+		report.RequestID = requestID(r)
+	}
+	if captureStack && !isHTTPError(v) {
+		report.Stack = captureFrames(2)
+	}
+	// A panic re-raised from a goroutine started with Go or Group is more
+	// useful with the stack of the goroutine that originally panicked
+	if err, ok := v.(error); ok {
+		var pe *PanicError
+		if errors.As(err, &pe) && len(pe.Stack) > 0 {
+			report.Stack = pe.Stack
+			report.GoroutineID = pe.GoroutineID
+		}
+	}
+	report.Fingerprint = fingerprint(v, report.Stack)
+	return report
+}
+
+// String formats the report for logs
+func (p PanicReport) String() string {
+	var b strings.Builder
+	if p.Method != "" || p.Path != "" {
+		fmt.Fprintf(&b, "%s %s: ", p.Method, p.Path)
+	}
+	b.WriteString(p.Message)
+	if len(p.Stack) > 0 {
+		fmt.Fprintf(&b, "\ngoroutine %d:", p.GoroutineID)
+		for _, f := range p.Stack {
+			b.WriteString("\n")
+			b.WriteString(f.String())
+		}
+	}
+	return b.String()
+}
+
+// panicMessage formats a panic value, including the full cause chain for errors
+func panicMessage(v any) string {
+	if err, ok := v.(error); ok {
+		return causeChain(err)
+	}
+	return fmt.Sprint(v)
+}
+
+// fingerprintFrames is the number of top stack frames in a fingerprint
+const fingerprintFrames = 3
+
+// fingerprintPatterns replace the variable parts of panic messages, such as
+// ids, indexes and quoted values, so that they do not split fingerprints
+var fingerprintPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), `"<str>"`},
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`0x[0-9a-fA-F]+|\b[0-9a-fA-F]{8,}\b`), "<hex>"},
+	{regexp.MustCompile(`[0-9]+`), "<n>"},
+}
+
+// fingerprint identifies panics of the same kind. It hashes the type of the
+// panic value, its message with
+// variable parts normalized and the functions of the top application stack
+// frames. Line numbers are left out so fingerprints survive unrelated edits.
+func fingerprint(v any, stack []Frame) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%T\n%s\n", v, normalizeMessage(panicMessage(v)))
+	for i, f := range stack {
+		if i == fingerprintFrames {
+			break
+		}
+		fmt.Fprintln(h, f.Function)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// normalizeMessage replaces the variable parts of a panic message
+func normalizeMessage(msg string) string {
+	for _, p := range fingerprintPatterns {
+		msg = p.re.ReplaceAllString(msg, p.repl)
+	}
+	return msg
+}
+
+// isHTTPError reports whether a panic value is, or wraps, an HTTPError
+func isHTTPError(v any) bool {
+	err, ok := v.(error)
+	if !ok {
+		return false
+	}
+	var httpErr HTTPError
+	return errors.As(err, &httpErr)
+}
+
+// captureFrames returns the current stack without runtime, must_go and
+// net/http frames, skipping the given number of callers.
+func captureFrames(skip int) []Frame {
+	pc := make([]uintptr, 64)
+	n := runtime.Callers(skip+1, pc)
+	frames := runtime.CallersFrames(pc[:n])
+
+	var stack []Frame
+	for {
+		frame, more := frames.Next()
+		if !trimmedFrame(frame.Function) {
+			stack = append(stack, Frame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// trimmedFrame reports whether a frame is removed from captured stacks
+func trimmedFrame(function string) bool {
+	for _, prefix := range trimmedPrefixes {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// goroutineID parses the id of the current goroutine from its stack header
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i >= 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
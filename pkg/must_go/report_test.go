@@ -0,0 +1,98 @@
+package must_go_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Devalanx/must_go/pkg/must_go"
+)
+
+func TestPanicReportStack(t *testing.T) {
+	var report must_go.PanicReport
+	rc := must_go.NewRecovery(must_go.OnPanic(func(r *http.Request, rep must_go.PanicReport) {
+		report = rep
+	}))
+
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["boom"] = 1
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", "/orders", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got: %d", w.Code)
+	}
+	if report.Method != "POST" || report.Path != "/orders" {
+		t.Errorf("Expected POST /orders, got: %s %s", report.Method, report.Path)
+	}
+	if report.GoroutineID == 0 {
+		t.Error("Expected goroutine id to be set")
+	}
+	if report.Time.IsZero() {
+		t.Error("Expected time to be set")
+	}
+	if !strings.Contains(report.Message, "nil map") {
+		t.Errorf("Expected nil map message, got: %s", report.Message)
+	}
+	if len(report.Stack) == 0 {
+		t.Fatal("Expected stack frames")
+	}
+	if fn := report.Stack[0].Function; !strings.HasPrefix(fn, "github.com/Devalanx/must_go/pkg/must_go_test.TestPanicReportStack") {
+		t.Errorf("Expected the panicking handler as top frame, got: %s", fn)
+	}
+	for _, f := range report.Stack {
+		if strings.HasPrefix(f.Function, "github.com/Devalanx/must_go/pkg/must_go.") ||
+			strings.HasPrefix(f.Function, "net/http.") ||
+			strings.HasPrefix(f.Function, "runtime.") {
+			t.Errorf("Expected frame %s to be trimmed", f.Function)
+		}
+	}
+}
+
+func TestPanicReportSkipsStackForHTTPError(t *testing.T) {
+	var report must_go.PanicReport
+	rc := must_go.NewRecovery(must_go.OnPanic(func(r *http.Request, rep must_go.PanicReport) {
+		report = rep
+	}))
+
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		must_go.MustNotFound(http.ErrNoCookie)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if len(report.Stack) != 0 {
+		t.Errorf("Expected no stack for HTTPError panics, got %d frames", len(report.Stack))
+	}
+}
+
+func TestPanicReportUsesGoroutineStack(t *testing.T) {
+	var report must_go.PanicReport
+	rc := must_go.NewRecovery(must_go.OnPanic(func(r *http.Request, rep must_go.PanicReport) {
+		report = rep
+	}))
+
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g, _ := must_go.NewGroup(r.Context())
+		g.Go(func() error {
+			var items []int
+			_ = items[3]
+			return nil
+		})
+		must_go.Must(g.Wait())
+	}))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got: %d", w.Code)
+	}
+	if len(report.Stack) == 0 {
+		t.Fatal("Expected stack frames")
+	}
+	if fn := report.Stack[0].Function; !strings.HasPrefix(fn, "github.com/Devalanx/must_go/pkg/must_go_test.TestPanicReportUsesGoroutineStack.func2.1") {
+		t.Errorf("Expected the panicking goroutine as top frame, got: %s", fn)
+	}
+}
@@ -0,0 +1,94 @@
+package must_go
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// RequestIDHeader is the header carrying the request correlation id
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the longest incoming request id that is accepted
+const maxRequestIDLength = 128
+
+// requestIDKey is the context key of the request id
+type requestIDKey struct{}
+
+// RequestIDMiddleware reads the request id from the X-Request-ID header, or
+// generates one if it is missing or malformed, stores it in the request
+// context and echoes it in the X-Request-ID response header.
+//
+// Install it outside the recovery middleware so error responses and panic
+// logs carry the generated id as well.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+// ContextWithRequestID returns a copy of ctx carrying the request id
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request id stored by RequestIDMiddleware,
+// or "" if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// requestID returns the id of a request from its context, falling back to
+// a well-formed X-Request-ID header for requests that did not pass through
+// RequestIDMiddleware
+func requestID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if id := RequestIDFromContext(r.Context()); id != "" {
+		return id
+	}
+	if id := r.Header.Get(RequestIDHeader); validRequestID(id) {
+		return id
+	}
+	return ""
+}
+
+// newRequestID generates a random 128-bit request id
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// newReference generates a short random id referring to a hidden error
+func newReference() string {
+	var b [8]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID reports whether an incoming request id is short and only
+// contains characters that are safe to echo in headers, bodies and logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':', c == '/', c == '+', c == '=':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package must_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming string
+		wantSame bool
+	}{
+		{"missing", "", false},
+		{"valid", "req-123", true},
+		{"malformed", "bad id\nwith newline", false},
+		{"too long", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromCtx string
+			handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fromCtx = RequestIDFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			echoed := w.Header().Get(RequestIDHeader)
+			if echoed == "" || echoed != fromCtx {
+				t.Fatalf("Expected echoed id to match context id, got: %q and %q", echoed, fromCtx)
+			}
+			if (echoed == tt.incoming) != tt.wantSame {
+				t.Errorf("Expected incoming id kept=%v, got: %q", tt.wantSame, echoed)
+			}
+			if !tt.wantSame && len(echoed) != 32 {
+				t.Errorf("Expected generated 32 character id, got: %q", echoed)
+			}
+		})
+	}
+}
+
+func TestRecoveryIncludesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	panicking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		MustNotFound(errNoSuchUser)
+	})
+
+	tests := []struct {
+		name    string
+		rc      *Recovery
+		wantKey string
+	}{
+		{"json", NewRecovery(WithLogger(logger)), `"request_id":"`},
+		{"problem", NewRecovery(WithLogger(logger), WithProblemDetails()), `"request_id":"`},
+		{"xml", NewRecovery(WithLogger(logger), WithRenderer(XMLRenderer{})), "<request_id>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			handler := RequestIDMiddleware(tt.rc.Handler(panicking))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest("GET", "/users/7", nil))
+
+			id := w.Header().Get(RequestIDHeader)
+			if id == "" {
+				t.Fatal("Expected X-Request-ID response header")
+			}
+			if !strings.Contains(w.Body.String(), tt.wantKey+id) {
+				t.Errorf("Expected body to contain request id %s, got: %s", id, w.Body.String())
+			}
+
+			var entry map[string]any
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to decode log entry %q: %v", buf.String(), err)
+			}
+			if entry["request_id"] != id {
+				t.Errorf("Expected request_id=%s in log, got: %v", id, entry["request_id"])
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package must_go
+
+import (
+	"errors"
+	"net/http"
+	"runtime"
+)
+
+// PanicError is a recovered panic returned as an error by Try, TryValue,
+// Handle, Go and Group. It unwraps to the panicked error, so errors.As still
+// finds an HTTPError and its status code. The embedded report carries the
+// stack of the goroutine that panicked.
+type PanicError struct {
+	PanicReport
+}
+
+// Error implements the error interface
+func (e *PanicError) Error() string {
+	if err, ok := e.Value.(error); ok {
+		return err.Error()
+	}
+	return "panic: " + panicMessage(e.Value)
+}
+
+// Unwrap returns the panicked error, if the panic value was an error
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// TryOption configures Try, TryValue and Handle
+type TryOption func(*tryConfig)
+
+type tryConfig struct {
+	recoverAll bool
+}
+
+// RecoverAll also recovers runtime errors, such as nil pointer
+// dereferences, and panics with values that are not errors. By default
+// those are re-panicked.
+func RecoverAll() TryOption {
+	return func(c *tryConfig) {
+		c.recoverAll = true
+	}
+}
+
+// Try calls fn and returns a must_go panic raised by it as an error
+func Try(fn func(), opts ...TryOption) (err error) {
+	defer Handle(&err, opts...)
+	fn()
+	return nil
+}
+
+// TryValue calls fn and returns its result, or a must_go panic raised by it
+// as an error
+func TryValue[T any](fn func() T, opts ...TryOption) (value T, err error) {
+	defer Handle(&err, opts...)
+	return fn(), nil
+}
+
+// Handle recovers a must_go panic and stores it in *errp. It must be called
+// directly with defer:
+//
+//	func load() (err error) {
+//		defer must_go.Handle(&err)
+//		...
+//	}
+//
+// Panics with an error are treated as must_go panics. Runtime errors and
+// panics with other values are re-panicked unless RecoverAll is given.
+func Handle(errp *error, opts ...TryOption) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	var cfg tryConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	if !cfg.recoverAll && !isMustPanic(v) {
+		panic(v)
+	}
+	*errp = newPanicError(v)
+}
+
+// StatusOf returns the status code of the HTTPError in err's chain, or 500
+func StatusOf(err error) int {
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// newPanicError converts a recovered panic value into a PanicError. It must
+// be called from the deferred function that recovered the panic for the
+// stack trace to include the panicking frames.
+func newPanicError(v any) *PanicError {
+	return &PanicError{PanicReport: newPanicReport(nil, v, true)}
+}
+
+// isMustPanic reports whether a panic value looks like it was raised by the
+// Must family: an error that is not a runtime.Error
+func isMustPanic(v any) bool {
+	err, ok := v.(error)
+	if !ok {
+		return false
+	}
+	_, isRuntime := err.(runtime.Error)
+	return !isRuntime
+}
@@ -0,0 +1,114 @@
+package must_go
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func TestTry(t *testing.T) {
+	if err := Try(func() {}); err != nil {
+		t.Errorf("Expected nil error, got: %v", err)
+	}
+
+	err := Try(func() {
+		MustNotFound(errNoSuchUser)
+	})
+	if StatusOf(err) != http.StatusNotFound {
+		t.Errorf("Expected status 404, got: %d", StatusOf(err))
+	}
+	if !errors.Is(err, errNoSuchUser) {
+		t.Error("Expected errors.Is to find the original cause")
+	}
+
+	err = Try(func() {
+		MustWithMessage(errNoSuchUser, "load user")
+	})
+	if err == nil || err.Error() != "load user: no such user" {
+		t.Errorf("Expected 'load user: no such user', got: %v", err)
+	}
+	if StatusOf(err) != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got: %d", StatusOf(err))
+	}
+}
+
+func TestTryValue(t *testing.T) {
+	n, err := TryValue(func() int {
+		return MustParse(strconv.Atoi("42"))
+	})
+	if n != 42 || err != nil {
+		t.Errorf("Expected 42 and nil, got: %d %v", n, err)
+	}
+
+	_, err = TryValue(func() int {
+		return MustParse(strconv.Atoi("abc"))
+	})
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("Expected *strconv.NumError in chain, got: %v", err)
+	}
+}
+
+func TestHandle(t *testing.T) {
+	load := func() (err error) {
+		defer Handle(&err)
+		MustBadRequest(fmt.Errorf("missing id"))
+		return nil
+	}
+	if err := load(); StatusOf(err) != http.StatusBadRequest {
+		t.Errorf("Expected status 400, got: %v", err)
+	}
+}
+
+func TestTryRuntimePanics(t *testing.T) {
+	boom := func() {
+		var m map[string]int
+		m["boom"] = 1
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected runtime panic to be re-panicked")
+			}
+		}()
+		_ = Try(boom)
+	}()
+
+	err := Try(boom, RecoverAll())
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("Expected *PanicError, got: %T", err)
+	}
+	if len(pe.Stack) == 0 {
+		t.Error("Expected a stack trace for runtime panics")
+	}
+	if StatusOf(err) != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got: %d", StatusOf(err))
+	}
+
+	err = Try(func() { panic("plain") }, RecoverAll())
+	if err == nil || err.Error() != "panic: plain" {
+		t.Errorf("Expected 'panic: plain', got: %v", err)
+	}
+}
+
+func TestMustPanicsWithCallerError(t *testing.T) {
+	defer func() {
+		if r := recover(); r != errNoSuchUser {
+			t.Errorf("Expected recover() to return the error passed to Must, got: %#v", r)
+		}
+	}()
+	Must(errNoSuchUser)
+}
+
+func TestTryPlainValuePanics(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "plain" {
+			t.Errorf("Expected non-error panic to be re-panicked, got: %v", r)
+		}
+	}()
+	_ = Try(func() { panic("plain") })
+}
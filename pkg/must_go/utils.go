@@ -31,9 +31,18 @@ func MustConflict(err error) {
 	MustHTTP(err, http.StatusConflict, "Resource conflict")
 }
 
-// MustValidation panics with 400 validation error if err is not nil
+// MustValidation panics with 400 validation error if err is not nil. Field
+// errors found in err, such as FieldError values joined with errors.Join,
+// are listed in the Errors of the HTTPError.
 func MustValidation(err error) {
-	MustHTTP(err, http.StatusBadRequest, "Validation error")
+	if err != nil {
+		panic(HTTPError{
+			StatusCode: http.StatusBadRequest,
+			Message:    "Validation error",
+			Cause:      err,
+			Errors:     fieldErrors(err),
+		})
+	}
 }
 
 // MustInternal panics with 500 if err is not nil
@@ -81,8 +90,53 @@ func MustParseHTTP[T any](result T, err error, statusCode int, message string) T
 	return result
 }
 
-// MustParseHTTPDefault panics with default HTTP error if parsing fails
+// MustParseHTTPDefault panics with an HTTPError resolved by DefaultRegistry if parsing fails
 func MustParseHTTPDefault[T any](result T, err error) T {
 	MustHTTPWithDefault(err)
 	return result
-} 
\ No newline at end of file
+}
+
+// Must2 panics if err is not nil, otherwise returns both values
+func Must2[A, B any](a A, b B, err error) (A, B) {
+	Must(err)
+	return a, b
+}
+
+// Must3 panics if err is not nil, otherwise returns all three values
+func Must3[A, B, C any](a A, b B, c C, err error) (A, B, C) {
+	Must(err)
+	return a, b, c
+}
+
+// MustHTTP2 takes the results of a call returning two values and an error
+// and returns a function that panics with an HTTP error if err is not nil,
+// otherwise returns both values. Go does not allow further arguments after
+// a multi-value call, so the status code and message are passed second:
+//
+//	host, port := must_go.MustHTTP2(net.SplitHostPort(addr))(http.StatusBadRequest, "Invalid address")
+func MustHTTP2[A, B any](a A, b B, err error) func(statusCode int, message string) (A, B) {
+	return func(statusCode int, message string) (A, B) {
+		MustHTTP(err, statusCode, message)
+		return a, b
+	}
+}
+
+// MustHTTP3 is like MustHTTP2 for calls returning three values and an error
+func MustHTTP3[A, B, C any](a A, b B, c C, err error) func(statusCode int, message string) (A, B, C) {
+	return func(statusCode int, message string) (A, B, C) {
+		MustHTTP(err, statusCode, message)
+		return a, b, c
+	}
+}
+
+// MustOK panics with HTTP error if ok is false, otherwise returns value.
+// It covers comma-ok lookups such as map reads and type assertions.
+func MustOK[T any](value T, ok bool, statusCode int, message string) T {
+	if !ok {
+		panic(HTTPError{
+			StatusCode: statusCode,
+			Message:    message,
+		})
+	}
+	return value
+}
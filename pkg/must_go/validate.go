@@ -0,0 +1,316 @@
+package must_go
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// FieldError describes a single invalid field of a request
+type FieldError struct {
+	Field   string `json:"field" xml:"field"`
+	Code    string `json:"code" xml:"code"`
+	Message string `json:"message" xml:"message"`
+}
+
+// Error implements the error interface
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidateStruct checks the validate tags of the struct v points to and
+// returns a 422 HTTPError listing every violation, or nil.
+//
+// The tag is a comma-separated list of rules:
+//
+//	required     the value must not be the zero value
+//	min=N        minimum number, or minimum length of strings and slices
+//	max=N        maximum number, or maximum length of strings and slices
+//	len=N        exact length of strings and slices
+//	oneof=a b c  the value must be one of the space-separated words
+//	email        the value must be a plain email address
+//	regexp=RE    the value must match RE; it must be the last rule
+//
+// Rules other than required are skipped for zero values, so optional fields
+// only need to be valid when they are set. Fields are named after their
+// query, path, header, form or json tag.
+func ValidateStruct(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	ve := ValidationErrors{errs: validateFields(rv)}
+	return ve.Err()
+}
+
+// ValidationErrors collects the field errors of a request so they can be
+// reported together. The zero value is ready to use.
+//
+//	var ve must_go.ValidationErrors
+//	ve.Check(req.Name != "", "name", "required", "is required")
+//	ve.Merge(must_go.ValidateStruct(&req))
+//	ve.Must()
+type ValidationErrors struct {
+	errs []FieldError
+}
+
+// Add records a field error
+func (v *ValidationErrors) Add(field, code, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Check records a field error if ok is false and returns ok
+func (v *ValidationErrors) Check(ok bool, field, code, message string) bool {
+	if !ok {
+		v.Add(field, code, message)
+	}
+	return ok
+}
+
+// Merge records the field errors found in err, which may be a FieldError,
+// an HTTPError carrying Errors, or any tree of them built with errors.Join
+// or %w. Other errors are recorded with code "invalid" and no field.
+func (v *ValidationErrors) Merge(err error) {
+	if err == nil {
+		return
+	}
+	if errs := fieldErrors(err); len(errs) > 0 {
+		v.errs = append(v.errs, errs...)
+		return
+	}
+	var httpErr HTTPError
+	if errors.As(err, &httpErr) {
+		v.Add("", "invalid", httpErr.Message)
+		return
+	}
+	v.Add("", "invalid", err.Error())
+}
+
+// Errs returns the recorded field errors
+func (v *ValidationErrors) Errs() []FieldError {
+	return append([]FieldError(nil), v.errs...)
+}
+
+// Err returns nil if no field error was recorded, otherwise a 422 HTTPError
+// listing them in Errors. The field errors are also joined into its Cause so
+// they appear in logs and can be matched with errors.As.
+func (v *ValidationErrors) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	causes := make([]error, len(v.errs))
+	for i, fe := range v.errs {
+		causes[i] = fe
+	}
+	return HTTPError{
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    "Validation failed",
+		Cause:      errors.Join(causes...),
+		Errors:     v.Errs(),
+	}
+}
+
+// Must panics with Err if any field error was recorded
+func (v *ValidationErrors) Must() {
+	if err := v.Err(); err != nil {
+		panic(err)
+	}
+}
+
+// fieldErrors returns the field errors in an error tree: FieldError values
+// and the Errors of HTTPErrors
+func fieldErrors(err error) []FieldError {
+	switch x := err.(type) {
+	case nil:
+		return nil
+	case FieldError:
+		return []FieldError{x}
+	case HTTPError:
+		if len(x.Errors) > 0 {
+			return x.Errors
+		}
+		return fieldErrors(x.Cause)
+	case interface{ Unwrap() []error }:
+		var errs []FieldError
+		for _, e := range x.Unwrap() {
+			errs = append(errs, fieldErrors(e)...)
+		}
+		return errs
+	case interface{ Unwrap() error }:
+		return fieldErrors(x.Unwrap())
+	}
+	return nil
+}
+
+// validateFields checks the validate tags of every field of a struct,
+// descending into embedded structs
+func validateFields(rv reflect.Value) []FieldError {
+	var errs []FieldError
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			errs = append(errs, validateFields(rv.Field(i))...)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if tag := sf.Tag.Get("validate"); tag != "" && tag != "-" {
+			errs = append(errs, validateField(fieldName(sf), rv.Field(i), tag)...)
+		}
+	}
+	return errs
+}
+
+// validateField applies the rules of a validate tag to a single value
+func validateField(name string, v reflect.Value, tag string) []FieldError {
+	var errs []FieldError
+	for _, rule := range splitRules(tag) {
+		code, arg, _ := strings.Cut(rule, "=")
+		if code != "required" && v.IsZero() {
+			continue
+		}
+		if msg := checkRule(code, arg, v); msg != "" {
+			errs = append(errs, FieldError{Field: name, Code: code, Message: msg})
+		}
+	}
+	return errs
+}
+
+// splitRules splits a validate tag on commas, keeping a regexp rule intact
+func splitRules(tag string) []string {
+	var rules []string
+	for tag != "" {
+		if strings.HasPrefix(tag, "regexp=") {
+			return append(rules, tag)
+		}
+		rule, rest, _ := strings.Cut(tag, ",")
+		if rule = strings.TrimSpace(rule); rule != "" {
+			rules = append(rules, rule)
+		}
+		tag = strings.TrimSpace(rest)
+	}
+	return rules
+}
+
+// checkRule returns the violation message of a rule, or "" if v satisfies it
+func checkRule(code, arg string, v reflect.Value) string {
+	switch code {
+	case "required":
+		if v.IsZero() || (hasLen(v) && v.Len() == 0) {
+			return "is required"
+		}
+	case "min", "max", "len":
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			panic(fmt.Errorf("must_go: invalid %s argument %q", code, arg))
+		}
+		return checkBound(code, n, v)
+	case "oneof":
+		options := strings.Fields(arg)
+		s := fmt.Sprint(v.Interface())
+		for _, option := range options {
+			if s == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "email":
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "must be a valid email address"
+		}
+	case "regexp":
+		if !compileRule(arg).MatchString(v.String()) {
+			return "must match " + arg
+		}
+	default:
+		panic(fmt.Errorf("must_go: unknown validation rule %q", code))
+	}
+	return ""
+}
+
+// checkBound checks min, max and len against numbers and lengths
+func checkBound(code string, n float64, v reflect.Value) string {
+	var value float64
+	var unit string
+	switch v.Kind() {
+	case reflect.String:
+		value, unit = float64(utf8.RuneCountInString(v.String())), " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		value, unit = float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+	default:
+		panic(fmt.Errorf("must_go: %s does not apply to %s", code, v.Type()))
+	}
+
+	bound := strconv.FormatFloat(n, 'f', -1, 64)
+	prefix := "must be "
+	if unit == " items" {
+		prefix = "must contain "
+	}
+	switch {
+	case code == "min" && value < n:
+		return prefix + "at least " + bound + unit
+	case code == "max" && value > n:
+		return prefix + "at most " + bound + unit
+	case code == "len" && value != n:
+		return prefix + "exactly " + bound + unit
+	}
+	return ""
+}
+
+// hasLen reports whether v has a length
+func hasLen(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// ruleRegexps caches the compiled expressions of regexp rules
+var ruleRegexps sync.Map
+
+// compileRule returns the compiled expression of a regexp rule
+func compileRule(expr string) *regexp.Regexp {
+	if re, ok := ruleRegexps.Load(expr); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(expr)
+	ruleRegexps.Store(expr, re)
+	return re
+}
+
+// fieldName returns the name of a struct field as seen by clients: its
+// query, path, header or form tag, else its json name, else the Go name
+func fieldName(sf reflect.StructField) string {
+	for _, key := range bindSources {
+		if name := sf.Tag.Get(key); name != "" && name != "-" {
+			return name
+		}
+	}
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
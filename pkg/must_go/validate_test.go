@@ -0,0 +1,126 @@
+package must_go
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestValidateStruct(t *testing.T) {
+	type signup struct {
+		Name  string   `json:"name" validate:"required,min=2,max=10"`
+		Email string   `json:"email" validate:"required,email"`
+		Age   int      `json:"age" validate:"min=18,max=130"`
+		Plan  string   `json:"plan" validate:"oneof=free pro"`
+		Code  string   `json:"code" validate:"len=4,regexp=^[A-Z]{2},[0-9]+$"`
+		Tags  []string `json:"tags" validate:"max=2"`
+		Note  string   `json:"note" validate:"min=5"`
+	}
+
+	valid := signup{Name: "John", Email: "john@example.com", Age: 30, Plan: "pro", Code: "AB,1", Tags: []string{"a"}}
+	if err := ValidateStruct(&valid); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	invalid := signup{Name: "J", Email: "John <john@example.com>", Age: 12, Plan: "gold", Code: "ABCDE", Tags: []string{"a", "b", "c"}}
+	err := ValidateStruct(invalid)
+	httpErr, ok := err.(HTTPError)
+	if !ok {
+		t.Fatalf("Expected HTTPError, got: %T %v", err, err)
+	}
+	if httpErr.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got: %d", httpErr.StatusCode)
+	}
+
+	want := []FieldError{
+		{Field: "name", Code: "min", Message: "must be at least 2 characters long"},
+		{Field: "email", Code: "email", Message: "must be a valid email address"},
+		{Field: "age", Code: "min", Message: "must be at least 18"},
+		{Field: "plan", Code: "oneof", Message: "must be one of: free, pro"},
+		{Field: "code", Code: "len", Message: "must be exactly 4 characters long"},
+		{Field: "code", Code: "regexp", Message: "must match ^[A-Z]{2},[0-9]+$"},
+		{Field: "tags", Code: "max", Message: "must contain at most 2 items"},
+	}
+	if !reflect.DeepEqual(httpErr.Errors, want) {
+		t.Errorf("Unexpected field errors:\n got: %+v\nwant: %+v", httpErr.Errors, want)
+	}
+
+	// Only required applies to zero values
+	err = ValidateStruct(&signup{})
+	httpErr, _ = err.(HTTPError)
+	if len(httpErr.Errors) != 2 || httpErr.Errors[0].Code != "required" || httpErr.Errors[1].Code != "required" {
+		t.Errorf("Expected two required errors, got: %+v", httpErr.Errors)
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	var ve ValidationErrors
+	if err := ve.Err(); err != nil {
+		t.Fatalf("Expected nil error, got: %v", err)
+	}
+
+	type profile struct {
+		Bio string `json:"bio" validate:"max=5"`
+	}
+	ve.Add("name", "required", "is required")
+	if !ve.Check(true, "email", "email", "must be a valid email address") {
+		t.Error("Expected Check to return true")
+	}
+	ve.Check(false, "age", "min", "must be at least 18")
+	ve.Merge(ValidateStruct(profile{Bio: "too long"}))
+	ve.Merge(errors.Join(
+		FieldError{Field: "plan", Code: "oneof", Message: "must be one of: free, pro"},
+		fmt.Errorf("check tags: %w", FieldError{Field: "tags", Code: "max", Message: "must contain at most 2 items"}),
+	))
+	ve.Merge(errors.New("passwords do not match"))
+
+	want := []FieldError{
+		{Field: "name", Code: "required", Message: "is required"},
+		{Field: "age", Code: "min", Message: "must be at least 18"},
+		{Field: "bio", Code: "max", Message: "must be at most 5 characters long"},
+		{Field: "plan", Code: "oneof", Message: "must be one of: free, pro"},
+		{Field: "tags", Code: "max", Message: "must contain at most 2 items"},
+		{Field: "", Code: "invalid", Message: "passwords do not match"},
+	}
+	if !reflect.DeepEqual(ve.Errs(), want) {
+		t.Errorf("Unexpected field errors:\n got: %+v\nwant: %+v", ve.Errs(), want)
+	}
+
+	err := ve.Err()
+	if StatusOf(err) != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status 422, got: %d", StatusOf(err))
+	}
+	var fe FieldError
+	if !errors.As(err, &fe) || fe.Field != "name" {
+		t.Errorf("Expected errors.As to find the first field error, got: %+v", fe)
+	}
+
+	defer func() {
+		httpErr, ok := recover().(HTTPError)
+		if !ok || len(httpErr.Errors) != len(want) {
+			t.Errorf("Expected HTTPError panic with %d field errors, got: %+v", len(want), httpErr)
+		}
+	}()
+	ve.Must()
+}
+
+func TestMustValidationFieldErrors(t *testing.T) {
+	defer func() {
+		httpErr, ok := recover().(HTTPError)
+		if !ok {
+			t.Fatal("Expected HTTPError panic")
+		}
+		if httpErr.StatusCode != http.StatusBadRequest || httpErr.Message != "Validation error" {
+			t.Errorf("Unexpected error: %v", httpErr)
+		}
+		if len(httpErr.Errors) != 2 || httpErr.Errors[1].Field != "email" {
+			t.Errorf("Expected both field errors, got: %+v", httpErr.Errors)
+		}
+	}()
+	MustValidation(errors.Join(
+		FieldError{Field: "name", Code: "required", Message: "is required"},
+		FieldError{Field: "email", Code: "required", Message: "is required"},
+	))
+}
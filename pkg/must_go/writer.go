@@ -0,0 +1,88 @@
+package must_go
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"time"
+)
+
+// responseWriter wraps the http.ResponseWriter handed to the next handler and
+// tracks whether the response has been committed, i.e. whether headers or
+// body bytes have already been sent to the client. start is when the
+// request entered the recovery middleware.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	written     int64
+	wroteHeader bool
+	hijacked    bool
+	start       time.Time
+}
+
+// newResponseWriter wraps w unless it is already a tracking writer
+func newResponseWriter(w http.ResponseWriter) *responseWriter {
+	if rw, ok := w.(*responseWriter); ok {
+		return rw
+	}
+	return &responseWriter{ResponseWriter: w, start: time.Now()}
+}
+
+// WriteHeader records the status code. Informational 1xx headers other than
+// 101 Switching Protocols do not commit the response.
+func (w *responseWriter) WriteHeader(code int) {
+	if !w.wroteHeader && (code >= 200 || code == http.StatusSwitchingProtocols) {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of body bytes written
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher. Flushing commits the response headers.
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack implements http.Hijacker
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying writer for http.ResponseController
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// committed reports whether it is too late to write an error response
+func (w *responseWriter) committed() bool {
+	return w.wroteHeader || w.hijacked
+}
+
+// discardWriter is handed to custom panic handlers when the response is
+// already committed. Everything written to it is dropped.
+type discardWriter struct {
+	header http.Header
+}
+
+func (w discardWriter) Header() http.Header { return w.header }
+
+func (w discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+func (w discardWriter) WriteHeader(int) {}
@@ -0,0 +1,120 @@
+package must_go
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecoveryCommittedResponse(t *testing.T) {
+	var logs bytes.Buffer
+	rc := NewRecovery(WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"partial":`)
+		MustInternal(errNoSuchUser)
+	}))
+
+	w := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Errorf("Expected http.ErrAbortHandler panic, got: %v", r)
+			}
+		}()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/stream", nil))
+	}()
+
+	if w.Code != http.StatusAccepted {
+		t.Errorf("Expected original status 202, got: %d", w.Code)
+	}
+	if w.Body.String() != `{"partial":` {
+		t.Errorf("Expected no error body to be appended, got: %s", w.Body.String())
+	}
+	if !strings.Contains(logs.String(), "response already committed") {
+		t.Errorf("Expected committed response to be logged, got: %s", logs.String())
+	}
+}
+
+func TestRecoveryCommittedResponseAbortsConnection(t *testing.T) {
+	srv := httptest.NewServer(RecoveryMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial body")
+		w.(http.Flusher).Flush()
+		panic("boom")
+	})))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Expected headers to arrive, got: %v", err)
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err == nil {
+		t.Error("Expected reading the aborted body to fail")
+	}
+}
+
+func TestResponseWriterInformationalHeaders(t *testing.T) {
+	rw := newResponseWriter(httptest.NewRecorder())
+	rw.WriteHeader(http.StatusEarlyHints)
+	if rw.committed() {
+		t.Error("Expected 103 Early Hints not to commit the response")
+	}
+	rw.WriteHeader(http.StatusOK)
+	if !rw.committed() || rw.status != http.StatusOK {
+		t.Errorf("Expected committed 200, got committed=%v status=%d", rw.committed(), rw.status)
+	}
+}
+
+func TestCustomRecoveryMiddlewareCommittedResponse(t *testing.T) {
+	var got any
+	handler := CustomRecoveryMiddleware(func(w http.ResponseWriter, r *http.Request, err interface{}) {
+		got = err
+		http.Error(w, "custom", http.StatusInternalServerError)
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "partial")
+		panic("boom")
+	}))
+
+	w := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Errorf("Expected http.ErrAbortHandler panic, got: %v", r)
+			}
+		}()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if got != "boom" {
+		t.Errorf("Expected the custom handler to see the panic, got: %v", got)
+	}
+	if w.Body.String() != "partial" {
+		t.Errorf("Expected the custom response to be discarded, got: %s", w.Body.String())
+	}
+}
+
+func TestHandlerFuncCommittedResponseWithoutRecovery(t *testing.T) {
+	handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		io.WriteString(w, "partial")
+		return errNoSuchUser
+	})
+
+	w := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if r := recover(); r != http.ErrAbortHandler {
+				t.Errorf("Expected http.ErrAbortHandler panic, got: %v", r)
+			}
+		}()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	}()
+
+	if w.Body.String() != "partial" {
+		t.Errorf("Expected no error body to be appended, got: %s", w.Body.String())
+	}
+}